@@ -0,0 +1,319 @@
+package config
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"strings"
+	"time"
+
+	"github.com/abibby/yabai3/i3parser"
+	"github.com/abibby/yabai3/parser"
+)
+
+type Include struct {
+	Path   string
+	Source string
+}
+
+// source is the file a node was parsed from.
+type source struct {
+	reader *parser.Reader
+}
+
+// NodeError returns err located at n in the file the node was parsed from.
+func (s source) NodeError(n parser.Node, err error) error {
+	return parser.NewNodeError(s.reader, n, err)
+}
+
+type BindSym struct {
+	*i3parser.BindSym
+	source
+}
+
+type ForWindow struct {
+	*i3parser.ForWindow
+	source
+}
+
+type Assign struct {
+	*i3parser.Assign
+	source
+}
+
+type Mode struct {
+	Name     string
+	BindSyms []*BindSym
+}
+
+type Workspace struct {
+	Name    string
+	Outputs []string
+}
+
+// Gaps are the gap between windows and the padding on each edge of a space.
+type Gaps struct {
+	Inner  int
+	Top    int
+	Right  int
+	Bottom int
+	Left   int
+}
+
+// set applies `gaps <type> <width>`. outer sets every edge, horizontal the
+// left and right and vertical the top and bottom.
+func (g *Gaps) set(gapType string, width int) {
+	switch gapType {
+	case "inner":
+		g.Inner = width
+	case "outer":
+		g.Top, g.Right, g.Bottom, g.Left = width, width, width, width
+	case "horizontal":
+		g.Left, g.Right = width, width
+	case "vertical":
+		g.Top, g.Bottom = width, width
+	case "top":
+		g.Top = width
+	case "right":
+		g.Right = width
+	case "bottom":
+		g.Bottom = width
+	case "left":
+		g.Left = width
+	}
+}
+
+// Size is a floating window size limit. Zero uses the default limit and -1
+// removes it.
+type Size struct {
+	Width  int
+	Height int
+}
+
+type Bar struct {
+	StatusCommand string
+}
+
+// Exec is a command from an exec or exec_always line.
+type Exec struct {
+	Command     string
+	NoStartupID bool
+}
+
+type Config struct {
+	Path     string
+	Source   string
+	Includes []*Include
+
+	Modes      []*Mode
+	Workspaces []*Workspace
+	Gaps       *Gaps
+	SmartGaps  bool
+	Bar        *Bar
+	Exec       []*Exec
+	ExecAlways []*Exec
+	ForWindows []*ForWindow
+	Assigns    []*Assign
+
+	FloatingMinimumSize Size
+	FloatingMaximumSize Size
+
+	WorkspaceAutoBackAndForth bool
+
+	// KillTimeout is how long `kill client` waits before sending SIGKILL,
+	// zero uses the default.
+	KillTimeout time.Duration
+
+	// Warnings are parts of the config that are accepted but have no
+	// effect.
+	Warnings []error
+}
+
+// Load parses the config file and every file it includes. Bindings outside
+// of a mode block belong to the default mode.
+func Load(file string) (*Config, error) {
+	r, err := parser.NewReaderFromFile(file)
+	if err != nil {
+		return nil, err
+	}
+	cfg := &Config{
+		Path:       file,
+		Source:     string(r.Source()),
+		Includes:   []*Include{},
+		Modes:      []*Mode{{Name: "default", BindSyms: []*BindSym{}}},
+		Workspaces: []*Workspace{},
+		Gaps:       &Gaps{},
+		Exec:       []*Exec{},
+		ExecAlways: []*Exec{},
+		ForWindows: []*ForWindow{},
+		Assigns:    []*Assign{},
+		Warnings:   []error{},
+	}
+	err = cfg.load(r, map[string]struct{}{file: {}})
+	if err != nil {
+		return nil, err
+	}
+	return cfg, nil
+}
+
+// Mode returns the mode called name or nil if there isn't one.
+func (c *Config) Mode(name string) *Mode {
+	for _, m := range c.Modes {
+		if m.Name == name {
+			return m
+		}
+	}
+	return nil
+}
+
+func (c *Config) load(r *parser.Reader, seen map[string]struct{}) error {
+	doc, err := i3parser.ParseDocument(nil, r)
+	if err != nil {
+		return err
+	}
+
+	for _, n := range doc.(*i3parser.Document).Children() {
+		switch n := n.(type) {
+		case *i3parser.BindSym:
+			defaultMode := c.Mode("default")
+			defaultMode.BindSyms = append(defaultMode.BindSyms, &BindSym{n, source{r}})
+		case *i3parser.Mode:
+			m := c.Mode(n.Name.Value)
+			if m == nil {
+				m = &Mode{Name: n.Name.Value, BindSyms: []*BindSym{}}
+				c.Modes = append(c.Modes, m)
+			}
+			for _, b := range n.BindSyms() {
+				m.BindSyms = append(m.BindSyms, &BindSym{b, source{r}})
+			}
+		case *i3parser.Workspace:
+			if n.Gaps != nil {
+				c.Warnings = append(c.Warnings, parser.NewNodeError(r, n.Gaps, fmt.Errorf("gaps for a single workspace are not supported and are ignored")))
+			}
+			if n.Output == nil {
+				break
+			}
+			outputs := make([]string, len(n.Outputs))
+			for i, o := range n.Outputs {
+				outputs[i] = o.Value
+			}
+			c.Workspaces = append(c.Workspaces, &Workspace{
+				Name:    n.Name.Value,
+				Outputs: outputs,
+			})
+		case *i3parser.Gaps:
+			width := int(n.With.Value)
+			if float64(width) != n.With.Value {
+				return parser.NewNodeError(r, n.With, fmt.Errorf("gaps must be a whole number of pixels"))
+			}
+			c.Gaps.set(n.Type.Value, width)
+		case *i3parser.FloatingSize:
+			size, err := floatingSize(r, n)
+			if err != nil {
+				return err
+			}
+			if n.Minimum() {
+				c.FloatingMinimumSize = size
+			} else {
+				c.FloatingMaximumSize = size
+			}
+		case *i3parser.SmartGaps:
+			c.SmartGaps = n.Value.Value
+		case *i3parser.AutoBackAndForth:
+			c.WorkspaceAutoBackAndForth = n.Value.Value
+		case *i3parser.KillTimeout:
+			if n.Value.Value <= 0 || float64(int(n.Value.Value)) != n.Value.Value {
+				return parser.NewNodeError(r, n.Value, fmt.Errorf("kill_timeout must be a positive whole number of milliseconds"))
+			}
+			c.KillTimeout = time.Duration(n.Value.Value) * time.Millisecond
+		case *i3parser.Exec:
+			e := &Exec{Command: n.Command.Value}
+			for _, f := range n.Flags {
+				if f.Value != "--no-startup-id" {
+					return parser.NewNodeError(r, f, fmt.Errorf("unknown exec option %s", f.Value))
+				}
+				e.NoStartupID = true
+			}
+			if n.Always() {
+				c.ExecAlways = append(c.ExecAlways, e)
+			} else {
+				c.Exec = append(c.Exec, e)
+			}
+		case *i3parser.Bar:
+			bar := &Bar{}
+			if s := n.StatusCommand(); s != nil {
+				bar.StatusCommand = s.Command.Value
+			}
+			c.Bar = bar
+		case *i3parser.ForWindow:
+			c.ForWindows = append(c.ForWindows, &ForWindow{n, source{r}})
+		case *i3parser.Assign:
+			c.Assigns = append(c.Assigns, &Assign{n, source{r}})
+		case *i3parser.Include:
+			err := c.include(r, n, seen)
+			if err != nil {
+				return err
+			}
+		}
+	}
+	return nil
+}
+
+func floatingSize(r *parser.Reader, n *i3parser.FloatingSize) (Size, error) {
+	size := Size{Width: int(n.Width.Value), Height: int(n.Height.Value)}
+	for _, v := range []*i3parser.Number{n.Width, n.Height} {
+		if float64(int(v.Value)) != v.Value || v.Value < -1 {
+			return Size{}, parser.NewNodeError(r, v, fmt.Errorf("floating sizes must be a whole number of pixels or -1"))
+		}
+	}
+	return size, nil
+}
+
+// include loads every file matching the include's glob. Like i3 every file
+// shares one set of variables, an included file sees the variables set in the
+// including file and in the files included before it. The including file is
+// parsed before its includes are loaded, so its own lines are expanded with
+// the variables it sets itself.
+func (c *Config) include(r *parser.Reader, n *i3parser.Include, seen map[string]struct{}) error {
+	matches, err := filepath.Glob(includePath(r.File(), n.Path.Value))
+	if err != nil {
+		return parser.NewNodeError(r, n.Path, err)
+	}
+	for _, match := range matches {
+		if _, ok := seen[match]; ok {
+			continue
+		}
+		seen[match] = struct{}{}
+
+		included, err := parser.NewReaderFromFile(match)
+		if err != nil {
+			return parser.NewNodeError(r, n.Path, err)
+		}
+		included.InheritVariables(r)
+
+		c.Includes = append(c.Includes, &Include{
+			Path:   match,
+			Source: string(included.Source()),
+		})
+		err = c.load(included, seen)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// includePath resolves an include the way i3 does, expanding ~ and treating
+// relative paths as relative to the including file.
+func includePath(file, p string) string {
+	if strings.HasPrefix(p, "~/") {
+		if home, err := os.UserHomeDir(); err == nil {
+			p = path.Join(home, p[2:])
+		}
+	}
+	if !path.IsAbs(p) {
+		p = path.Join(path.Dir(file), p)
+	}
+	return p
+}
@@ -0,0 +1,148 @@
+package config
+
+import (
+	"os"
+	"path"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func writeFile(t *testing.T, dir, name, src string) string {
+	p := path.Join(dir, name)
+	err := os.MkdirAll(path.Dir(p), 0o755)
+	if err != nil {
+		t.Fatal(err)
+	}
+	err = os.WriteFile(p, []byte(src), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	return p
+}
+
+func TestLoad(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, dir, "config", `set $mod Mod1
+set $mod2 Mod4
+bindsym $mod+a focus left
+bindsym $mod2+a focus right
+workspace web output 2 1
+gaps inner 5
+gaps outer 10
+exec kitty
+exec_always --no-startup-id yabai3 yabairc
+bar {
+	status_command i3status
+}
+include config.d/*.conf
+`)
+	writeFile(t, dir, "config.d/resize.conf", "mode \"resize\" {\nbindsym $mod+escape mode default\n}\n")
+
+	cfg, err := Load(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, file, cfg.Path)
+	if assert.Len(t, cfg.Includes, 1) {
+		assert.Equal(t, path.Join(dir, "config.d/resize.conf"), cfg.Includes[0].Path)
+	}
+
+	names := []string{}
+	for _, m := range cfg.Modes {
+		names = append(names, m.Name)
+	}
+	assert.Equal(t, []string{"default", "resize"}, names)
+
+	defaultMode := cfg.Mode("default")
+	if assert.Len(t, defaultMode.BindSyms, 2) {
+		assert.Equal(t, "Mod1+a", defaultMode.BindSyms[0].Keys.Value)
+		assert.Equal(t, "Mod4+a", defaultMode.BindSyms[1].Keys.Value)
+	}
+	assert.Equal(t, "Mod1+escape", cfg.Mode("resize").BindSyms[0].Keys.Value)
+
+	assert.Equal(t, []*Workspace{{Name: "web", Outputs: []string{"2", "1"}}}, cfg.Workspaces)
+	assert.Equal(t, &Gaps{Inner: 5, Top: 10, Right: 10, Bottom: 10, Left: 10}, cfg.Gaps)
+	assert.Equal(t, []*Exec{{Command: "kitty"}}, cfg.Exec)
+	assert.Equal(t, []*Exec{{Command: "yabai3 yabairc", NoStartupID: true}}, cfg.ExecAlways)
+	assert.Equal(t, &Bar{StatusCommand: "i3status"}, cfg.Bar)
+}
+
+func TestLoad_includeVariables(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, dir, "config", `set $mod Mod1
+include config.d/*.conf
+workspace 1 gaps inner 5
+gaps vertical 5
+`)
+	writeFile(t, dir, "config.d/1-vars.conf", "set $term kitty\n")
+	writeFile(t, dir, "config.d/2-exec.conf", "exec $term\nbindsym $mod+a focus left\n")
+
+	cfg, err := Load(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []*Exec{{Command: "kitty"}}, cfg.Exec)
+	assert.Equal(t, "Mod1+a", cfg.Mode("default").BindSyms[0].Keys.Value)
+	assert.Empty(t, cfg.Workspaces)
+	assert.Equal(t, &Gaps{Top: 5, Bottom: 5}, cfg.Gaps)
+	if assert.Len(t, cfg.Warnings, 1) {
+		assert.EqualError(t, cfg.Warnings[0], file+":3:13: gaps for a single workspace are not supported and are ignored")
+	}
+}
+
+func TestLoad_includeError(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, dir, "config", "include extra.conf\n")
+	extra := writeFile(t, dir, "extra.conf", "\ngaps inner 1.5\n")
+
+	_, err := Load(file)
+	assert.EqualError(t, err, extra+":2:12: gaps must be a whole number of pixels")
+}
+
+func TestLoad_floatingSize(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, dir, "config", "floating_minimum_size 75 x 50\nfloating_maximum_size -1 x 600\n")
+
+	cfg, err := Load(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, Size{Width: 75, Height: 50}, cfg.FloatingMinimumSize)
+	assert.Equal(t, Size{Width: -1, Height: 600}, cfg.FloatingMaximumSize)
+
+	file = writeFile(t, dir, "bad", "floating_maximum_size 10.5 x 600\n")
+	_, err = Load(file)
+	assert.EqualError(t, err, file+":1:23: floating sizes must be a whole number of pixels or -1")
+}
+
+func TestLoad_workspaceAutoBackAndForth(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, dir, "config", "workspace_auto_back_and_forth yes\n")
+
+	cfg, err := Load(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.True(t, cfg.WorkspaceAutoBackAndForth)
+
+	file = writeFile(t, dir, "bad", "workspace_auto_back_and_forth maybe\n")
+	_, err = Load(file)
+	assert.EqualError(t, err, file+":1:31: invalid bool value maybe, must be on, off, yes or no")
+}
+
+func TestLoad_killTimeout(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, dir, "config", "kill_timeout 1500\n")
+
+	cfg, err := Load(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 1500*time.Millisecond, cfg.KillTimeout)
+
+	file = writeFile(t, dir, "bad", "kill_timeout 0\n")
+	_, err = Load(file)
+	assert.EqualError(t, err, file+":1:14: kill_timeout must be a positive whole number of milliseconds")
+}
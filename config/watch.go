@@ -0,0 +1,79 @@
+package config
+
+import (
+	"context"
+	"os"
+	"time"
+)
+
+// Files returns the config file followed by every file it included.
+func (c *Config) Files() []string {
+	files := []string{c.Path}
+	for _, include := range c.Includes {
+		files = append(files, include.Path)
+	}
+	return files
+}
+
+type fileState struct {
+	exists  bool
+	modTime time.Time
+	size    int64
+}
+
+func statFile(file string) fileState {
+	info, err := os.Stat(file)
+	if err != nil {
+		return fileState{}
+	}
+	return fileState{
+		exists:  true,
+		modTime: info.ModTime(),
+		size:    info.Size(),
+	}
+}
+
+// Watch checks files every interval and sends on the returned channel each
+// time one of them is changed, created or removed. The channel is closed once
+// ctx is done.
+func Watch(ctx context.Context, files []string, interval time.Duration) <-chan struct{} {
+	changes := make(chan struct{})
+	states := map[string]fileState{}
+	for _, file := range files {
+		states[file] = statFile(file)
+	}
+
+	go func() {
+		defer close(changes)
+
+		ticker := time.NewTicker(interval)
+		defer ticker.Stop()
+
+		for {
+			select {
+			case <-ctx.Done():
+				return
+			case <-ticker.C:
+			}
+
+			changed := false
+			for file, state := range states {
+				next := statFile(file)
+				if next != state {
+					states[file] = next
+					changed = true
+				}
+			}
+			if !changed {
+				continue
+			}
+
+			select {
+			case <-ctx.Done():
+				return
+			case changes <- struct{}{}:
+			}
+		}
+	}()
+	return changes
+}
@@ -0,0 +1,35 @@
+package config
+
+import (
+	"context"
+	"os"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestWatch(t *testing.T) {
+	dir := t.TempDir()
+	file := writeFile(t, dir, "config", "gaps inner 5\n")
+	include := writeFile(t, dir, "extra.conf", "gaps outer 5\n")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	changes := Watch(ctx, []string{file, include}, 10*time.Millisecond)
+
+	err := os.WriteFile(include, []byte("gaps outer 10\n"), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	select {
+	case _, ok := <-changes:
+		assert.True(t, ok)
+	case <-time.After(time.Second):
+		t.Fatal("no change detected")
+	}
+
+	cancel()
+	_, ok := <-changes
+	assert.False(t, ok)
+}
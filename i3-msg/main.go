@@ -35,7 +35,8 @@ func check(err error) {
 func main() {
 	msgType := pflag.StringP("type", "t", "command", "Send ipc message.")
 	quiet := pflag.BoolP("quiet", "q", false, "Only send ipc message and suppress the output of the response.")
-	monitor := pflag.BoolP("monitor", "m", false, "Only send ipc message and suppress the output of the response.")
+	monitor := pflag.BoolP("monitor", "m", false, "Instead of exiting right after receiving the first subscribed event, wait indefinitely for all of them.")
+	socket := pflag.StringP("socket", "s", server.SocketPath(), "Use the specified socket path.")
 	pflag.Parse()
 
 	args := pflag.Args()
@@ -43,20 +44,39 @@ func main() {
 		fmt.Println("i3-msg <command...>")
 		return
 	}
-	conn, err := net.Dial("tcp4", fmt.Sprintf("127.0.0.1:%d", server.PORT))
+	t, ok := server.ParseMessageType(*msgType)
+	if !ok {
+		check(fmt.Errorf("invalid message type %s", *msgType))
+	}
+
+	conn, err := net.Dial("unix", *socket)
 	check(err)
 
 	defer conn.Close()
 
-	err = json.NewEncoder(conn).Encode(&server.Request{
-		Type:    *msgType,
-		Message: strings.Join(args, " "),
-		Monitor: *monitor,
-	})
+	err = server.WriteMessage(conn, t, []byte(strings.Join(args, " ")))
 	check(err)
 
+	_, reply, err := server.ReadMessage(conn)
+	check(err)
 	if !*quiet {
-		_, err = io.Copy(os.Stdout, conn)
+		fmt.Println(string(reply))
+	}
+
+	if t != server.MessageSubscribe {
+		return
+	}
+	for {
+		_, event, err := server.ReadMessage(conn)
+		if errors.Is(err, io.EOF) {
+			return
+		}
 		check(err)
+		if !*quiet {
+			fmt.Println(string(event))
+		}
+		if !*monitor {
+			return
+		}
 	}
 }
@@ -0,0 +1,102 @@
+package i3parser
+
+import (
+	"fmt"
+
+	"github.com/abibby/yabai3/parser"
+)
+
+const assignArrow = "→"
+
+// Assign moves new windows matching its criteria to a workspace, or to an
+// output when Output is set.
+type Assign struct {
+	*parser.Section
+	Assign     *Exact
+	Conditions *Conditions
+	Workspace  *Exact
+	Output     *Exact
+	Number     *Exact
+	Target     *Text
+}
+
+func ParseAssign(parent parser.Node, block *parser.Reader) (parser.Node, error) {
+	tx := block.BeginTx()
+	defer tx.Rollback()
+
+	a := &Assign{}
+
+	assign, err := ExactParser("assign")(a, block)
+	if err != nil {
+		return nil, parser.ErrWrongParser
+	}
+	a.Assign = assign.(*Exact)
+
+	skipInlineWhitespace(block)
+
+	conditions, err := ParseConditions(a, block)
+	if err != nil {
+		return nil, err
+	}
+	a.Conditions = conditions.(*Conditions)
+
+	skipInlineWhitespace(block)
+	if string(block.PeakN(len(assignArrow))) == assignArrow {
+		block.Advance(len(assignArrow))
+		skipInlineWhitespace(block)
+	}
+
+	if output, err := ExactParser("output")(a, block); err == nil {
+		a.Output = output.(*Exact)
+		skipInlineWhitespace(block)
+	} else if workspace, err := ExactParser("workspace")(a, block); err == nil {
+		a.Workspace = workspace.(*Exact)
+		skipInlineWhitespace(block)
+	}
+	if a.Output == nil {
+		if number, err := ExactParser("number")(a, block); err == nil {
+			a.Number = number.(*Exact)
+			skipInlineWhitespace(block)
+		}
+	}
+
+	target, err := parseAssignTarget(a, block)
+	if err != nil {
+		return nil, err
+	}
+	a.Target = target
+
+	err = expectLineEnd(block)
+	if err != nil {
+		return nil, err
+	}
+
+	a.Section = tx.Commit()
+	return a, nil
+}
+
+// parseAssignTarget reads a quoted string or the rest of the line.
+func parseAssignTarget(parent parser.Node, block *parser.Reader) (*Text, error) {
+	b := block.Peak()
+	if b == '"' || b == '\'' {
+		s, err := ParseString(parent, block)
+		if err != nil {
+			return nil, err
+		}
+		return NewText(s.(*String).Section, s.(*String).Value), nil
+	}
+	if isLineEnd(b) {
+		return nil, parser.NewError(block, fmt.Errorf("expected a workspace or output"))
+	}
+	t, err := ParseText(parent, block)
+	if err != nil {
+		return nil, err
+	}
+	text := t.(*Text)
+	// a variable holding a quoted name is only expanded after the quotes are
+	// checked for
+	if v := text.Value; len(v) >= 2 && (v[0] == '"' || v[0] == '\'') && v[len(v)-1] == v[0] {
+		text.Value = v[1 : len(v)-1]
+	}
+	return text, nil
+}
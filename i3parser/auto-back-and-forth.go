@@ -0,0 +1,41 @@
+package i3parser
+
+import (
+	"github.com/abibby/yabai3/parser"
+)
+
+// AutoBackAndForth is a workspace_auto_back_and_forth directive.
+type AutoBackAndForth struct {
+	*parser.Section
+	AutoBackAndForth *Exact
+	Value            *Bool
+}
+
+func ParseAutoBackAndForth(parent parser.Node, block *parser.Reader) (parser.Node, error) {
+	tx := block.BeginTx()
+	defer tx.Rollback()
+
+	a := &AutoBackAndForth{}
+
+	name, err := ExactParser("workspace_auto_back_and_forth")(a, block)
+	if err != nil {
+		return nil, parser.ErrWrongParser
+	}
+	a.AutoBackAndForth = name.(*Exact)
+
+	skipInlineWhitespace(block)
+
+	value, err := ParseBool(a, block)
+	if err != nil {
+		return nil, err
+	}
+	a.Value = value.(*Bool)
+
+	err = expectLineEnd(block)
+	if err != nil {
+		return nil, err
+	}
+
+	a.Section = tx.Commit()
+	return a, nil
+}
@@ -0,0 +1,78 @@
+package i3parser
+
+import (
+	"github.com/abibby/yabai3/parser"
+)
+
+type Bar struct {
+	*parser.Section
+	Bar      *Exact
+	Children []parser.Node
+}
+
+func (b *Bar) StatusCommand() *StatusCommand {
+	for _, c := range b.Children {
+		if s, ok := c.(*StatusCommand); ok {
+			return s
+		}
+	}
+	return nil
+}
+
+var barChildren = []parser.Parser{
+	ParseWhitespace,
+	ParseComment,
+	ParseStatusCommand,
+	DirectiveParser(),
+}
+
+func ParseBar(parent parser.Node, block *parser.Reader) (parser.Node, error) {
+	tx := block.BeginTx()
+	defer tx.Rollback()
+
+	b := &Bar{}
+
+	bar, err := ExactParser("bar")(b, block)
+	if err != nil {
+		return nil, parser.ErrWrongParser
+	}
+	b.Bar = bar.(*Exact)
+
+	b.Children, err = parseBlock(b, block, barChildren...)
+	if err != nil {
+		return nil, err
+	}
+
+	b.Section = tx.Commit()
+	return b, nil
+}
+
+type StatusCommand struct {
+	*parser.Section
+	StatusCommand *Exact
+	Command       *Text
+}
+
+func ParseStatusCommand(parent parser.Node, block *parser.Reader) (parser.Node, error) {
+	tx := block.BeginTx()
+	defer tx.Rollback()
+
+	s := &StatusCommand{}
+
+	statusCommand, err := ExactParser("status_command")(s, block)
+	if err != nil {
+		return nil, parser.ErrWrongParser
+	}
+	s.StatusCommand = statusCommand.(*Exact)
+
+	skipInlineWhitespace(block)
+
+	command, err := ParseText(s, block)
+	if err != nil {
+		return nil, err
+	}
+	s.Command = command.(*Text)
+
+	s.Section = tx.Commit()
+	return s, nil
+}
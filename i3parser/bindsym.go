@@ -0,0 +1,56 @@
+package i3parser
+
+import (
+	"github.com/abibby/yabai3/parser"
+)
+
+type BindSym struct {
+	*parser.Section
+	BindSym  *Exact
+	Flags    []*Word
+	Keys     *Word
+	Commands *Commands
+}
+
+func ParseBindSym(parent parser.Node, block *parser.Reader) (parser.Node, error) {
+	tx := block.BeginTx()
+	defer tx.Rollback()
+
+	b := &BindSym{}
+
+	bindSym, err := ExactParser("bindsym")(b, block)
+	if err != nil {
+		return nil, parser.ErrWrongParser
+	}
+	b.BindSym = bindSym.(*Exact)
+
+	b.Flags, err = parseFlags(b, block)
+	if err != nil {
+		return nil, err
+	}
+
+	keys, err := ParseWord(b, block)
+	if err != nil {
+		return nil, err
+	}
+	b.Keys = keys.(*Word)
+
+	skipInlineWhitespace(block)
+	if isLineEnd(block.Peak()) {
+		return nil, parser.NewError(block, errExpectedCommand)
+	}
+
+	commands, err := ParseCommands(b, block)
+	if err != nil {
+		return nil, err
+	}
+	b.Commands = commands.(*Commands)
+
+	err = expectLineEnd(block)
+	if err != nil {
+		return nil, err
+	}
+
+	b.Section = tx.Commit()
+	return b, nil
+}
@@ -0,0 +1,43 @@
+package i3parser
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/abibby/yabai3/parser"
+)
+
+// parseBlock parses a { } block, each line inside it must match one of
+// parsers.
+func parseBlock(parent parser.Node, block *parser.Reader, parsers ...parser.Parser) ([]parser.Node, error) {
+	skipInlineWhitespace(block)
+	if block.Peak() != '{' {
+		return nil, parser.NewError(block, fmt.Errorf("expected {"))
+	}
+	block.Advance(1)
+	err := expectLineEnd(block)
+	if err != nil {
+		return nil, err
+	}
+
+	children := []parser.Node{}
+	for {
+		c, err := parser.NextNode(parent, block, parsers...)
+		if errors.Is(err, parser.ErrNoNode) {
+			break
+		} else if err != nil {
+			return nil, err
+		}
+		children = append(children, c)
+	}
+
+	switch block.Peak() {
+	case '}':
+		block.Advance(1)
+		return children, expectLineEnd(block)
+	case parser.EOF:
+		return nil, parser.NewError(block, fmt.Errorf("expected }"))
+	default:
+		return nil, parser.NewError(block, fmt.Errorf("unexpected content \"%s\"", block.PeakLine()))
+	}
+}
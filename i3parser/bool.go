@@ -22,11 +22,18 @@ func ParseBool(parent parser.Node, block *parser.Reader) (parser.Node, error) {
 	tx := block.BeginTx()
 	defer tx.Rollback()
 
-	valueStr := string(block.ReadWord())
-
-	if valueStr != "on" && valueStr != "off" {
-		return nil, fmt.Errorf("invalid bool value %s, must be on or off", valueStr)
+	valueStr := string(block.PeakWord())
+
+	var value bool
+	switch valueStr {
+	case "on", "yes":
+		value = true
+	case "off", "no":
+		value = false
+	default:
+		return nil, parser.NewError(block, fmt.Errorf("invalid bool value %s, must be on, off, yes or no", valueStr))
 	}
+	block.Advance(len(valueStr))
 
-	return NewBool(tx.Commit(), valueStr == "on"), nil
+	return NewBool(tx.Commit(), value), nil
 }
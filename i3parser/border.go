@@ -1,20 +1,35 @@
 package i3parser
 
 import (
+	"fmt"
+	"slices"
+
 	"github.com/abibby/yabai3/parser"
 )
 
 var KindBorder = CommandKind("border")
 
+var borderStyles = []string{"none", "normal", "pixel", "toggle"}
+
 type Border struct {
 	*parser.Section
 	Border *Exact
+	Style  *Word
+	Width  *Number
 }
 
 func (b *Border) Kind() CommandKind {
 	return KindBorder
 }
 
+func (b *Border) Args() []string {
+	args := []string{string(KindBorder), b.Style.Value}
+	if b.Width != nil {
+		args = append(args, fmt.Sprint(b.Width.Value))
+	}
+	return args
+}
+
 func ParseBorder(parent parser.Node, block *parser.Reader) (parser.Node, error) {
 	tx := block.BeginTx()
 	defer tx.Rollback()
@@ -27,6 +42,27 @@ func ParseBorder(parent parser.Node, block *parser.Reader) (parser.Node, error)
 	}
 	b.Border = border.(*Exact)
 
+	skipInlineWhitespace(block)
+
+	style, err := ParseWord(b, block)
+	if err != nil {
+		return nil, err
+	}
+	b.Style = style.(*Word)
+	if !slices.Contains(borderStyles, b.Style.Value) {
+		return nil, parser.NewNodeError(block, b.Style, fmt.Errorf("invalid border style %s", b.Style.Value))
+	}
+
+	skipInlineWhitespace(block)
+
+	if (b.Style.Value == "normal" || b.Style.Value == "pixel") && block.Peak() >= '0' && block.Peak() <= '9' {
+		width, err := ParseNumber(b, block)
+		if err != nil {
+			return nil, err
+		}
+		b.Width = width.(*Number)
+	}
+
 	b.Section = tx.Commit()
 	return b, nil
 }
@@ -1,14 +1,148 @@
 package i3parser
 
-import "github.com/abibby/yabai3/parser"
+import (
+	"errors"
+
+	"github.com/abibby/yabai3/parser"
+)
 
 type CommandKind string
 
+var errExpectedCommand = errors.New("expected a command")
+
 type Command interface {
 	parser.Node
 	Kind() CommandKind
+	// Args returns the words of the command, starting with its kind.
+	Args() []string
+}
+
+// BasicCommand is any command without a more specific node.
+type BasicCommand struct {
+	*parser.Section
+	Words []*Word
+}
+
+func (c *BasicCommand) Kind() CommandKind {
+	return CommandKind(c.Words[0].Value)
+}
+
+func (c *BasicCommand) Args() []string {
+	args := make([]string, len(c.Words))
+	for i, w := range c.Words {
+		args[i] = w.Value
+	}
+	return args
+}
+
+func ParseBasicCommand(parent parser.Node, block *parser.Reader) (parser.Node, error) {
+	tx := block.BeginTx()
+	defer tx.Rollback()
+
+	c := &BasicCommand{}
+	words, err := parseWords(c, block)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) == 0 {
+		return nil, parser.NewError(block, errExpectedCommand)
+	}
+	c.Words = words
+
+	c.Section = tx.Commit()
+	return c, nil
 }
 
 func ParseCommand(parent parser.Node, block *parser.Reader) (parser.Node, error) {
-	return parser.NextNode(parent, block)
+	return parser.NextNode(parent, block, ParseExecCommand, ParseBorder, ParseBasicCommand)
+}
+
+// CommandChain is a list of commands separated by , that share the same
+// criteria.
+type CommandChain struct {
+	*parser.Section
+	Conditions *Conditions
+	Commands   []Command
+}
+
+func ParseCommandChain(parent parser.Node, block *parser.Reader) (parser.Node, error) {
+	tx := block.BeginTx()
+	defer tx.Rollback()
+
+	chain := &CommandChain{
+		Commands: []Command{},
+	}
+
+	if block.Peak() == '[' {
+		conditions, err := ParseConditions(chain, block)
+		if err != nil {
+			return nil, err
+		}
+		chain.Conditions = conditions.(*Conditions)
+		skipInlineWhitespace(block)
+	}
+
+	for {
+		command, err := ParseCommand(chain, block)
+		if err != nil {
+			return nil, err
+		}
+		chain.Commands = append(chain.Commands, command.(Command))
+
+		skipInlineWhitespace(block)
+		if block.Peak() != ',' {
+			break
+		}
+		block.Advance(1)
+		skipInlineWhitespace(block)
+	}
+
+	chain.Section = tx.Commit()
+	return chain, nil
+}
+
+// Commands is a list of command chains separated by ;.
+type Commands struct {
+	*parser.Section
+	Chains []*CommandChain
+}
+
+// List returns every command in every chain.
+func (c *Commands) List() []Command {
+	commands := []Command{}
+	for _, chain := range c.Chains {
+		commands = append(commands, chain.Commands...)
+	}
+	return commands
+}
+
+// ParseCommands parses commands up to the end of the line.
+func ParseCommands(parent parser.Node, block *parser.Reader) (parser.Node, error) {
+	tx := block.BeginTx()
+	defer tx.Rollback()
+
+	c := &Commands{
+		Chains: []*CommandChain{},
+	}
+	for {
+		skipInlineWhitespace(block)
+		chain, err := ParseCommandChain(c, block)
+		if err != nil {
+			return nil, err
+		}
+		c.Chains = append(c.Chains, chain.(*CommandChain))
+
+		skipInlineWhitespace(block)
+		if block.Peak() != ';' {
+			break
+		}
+		block.Advance(1)
+		skipInlineWhitespace(block)
+		if isLineEnd(block.Peak()) {
+			break
+		}
+	}
+
+	c.Section = tx.Commit()
+	return c, nil
 }
@@ -0,0 +1,27 @@
+package i3parser
+
+import (
+	"github.com/abibby/yabai3/parser"
+)
+
+type Comment struct {
+	*parser.Section
+}
+
+func NewComment(s *parser.Section) *Comment {
+	return &Comment{
+		Section: s,
+	}
+}
+
+func ParseComment(parent parser.Node, block *parser.Reader) (parser.Node, error) {
+	tx := block.BeginTx()
+	defer tx.Rollback()
+
+	if block.Peak() != '#' {
+		return nil, parser.ErrWrongParser
+	}
+	block.ReadUntil([]byte("\n"))
+
+	return NewComment(tx.Commit()), nil
+}
@@ -6,6 +6,8 @@ import (
 	"github.com/abibby/yabai3/parser"
 )
 
+// Condition is a single criterion, Value is nil for criteria like tiling and
+// floating that don't take one.
 type Condition struct {
 	Type  *Identifier
 	Value *String
@@ -34,33 +36,59 @@ func ParseConditions(parent parser.Node, block *parser.Reader) (parser.Node, err
 		return nil, parser.NewError(block, fmt.Errorf("expected [ received %c", b))
 	}
 
-	c := NewConditions(tx.Commit())
+	c := NewConditions(nil)
+	skipInlineWhitespace(block)
 	for block.Peak() != ']' {
+		if isLineEnd(block.Peak()) {
+			return nil, parser.NewError(block, fmt.Errorf("expected ]"))
+		}
+
 		t, err := ParseIdentifier(c, block)
 		if err != nil {
-			return nil, parser.NewError(block, err)
+			return nil, err
 		}
+		condition := &Condition{
+			Type: t.(*Identifier),
+		}
+
+		skipInlineWhitespace(block)
 
-		block.SkipWhitespace()
+		if block.Peak() == '=' {
+			block.Advance(1)
+			skipInlineWhitespace(block)
 
-		b = block.ReadNextByte()
-		if b != '=' {
-			return nil, parser.NewError(block, fmt.Errorf("expected = received %c", b))
+			v, err := parseConditionValue(c, block)
+			if err != nil {
+				return nil, err
+			}
+			condition.Value = v
 		}
 
-		block.SkipWhitespace()
+		c.Conditions = append(c.Conditions, condition)
+		skipInlineWhitespace(block)
+	}
+	block.Advance(1)
 
-		v, err := ParseString(c, block)
+	c.Section = tx.Commit()
+	return c, nil
+}
+
+func parseConditionValue(parent parser.Node, block *parser.Reader) (*String, error) {
+	b := block.Peak()
+	if b == '"' || b == '\'' {
+		v, err := ParseString(parent, block)
 		if err != nil {
-			return nil, parser.NewError(block, err)
+			return nil, err
 		}
-		c.Conditions = append(c.Conditions, &Condition{
-			Type:  t.(*Identifier),
-			Value: v.(*String),
-		})
-		block.SkipWhitespace()
+		return v.(*String), nil
 	}
-	block.Advance(1)
 
-	return c, nil
+	tx := block.BeginTx()
+	defer tx.Rollback()
+
+	value := block.ReadUntil([]byte(" \t\r\n]"))
+	if len(value) == 0 {
+		return nil, parser.NewError(block, fmt.Errorf("expected a value"))
+	}
+	return NewString(tx.Commit(), block.Expand(string(value))), nil
 }
@@ -0,0 +1,93 @@
+package i3parser
+
+import (
+	"slices"
+
+	"github.com/abibby/yabai3/parser"
+)
+
+// ignoredDirectives are i3 directives that are accepted but have no effect
+// on yabai.
+var ignoredDirectives = []string{
+	"bindcode",
+	"client.background",
+	"client.focused",
+	"client.focused_inactive",
+	"client.focused_tab_title",
+	"client.placeholder",
+	"client.unfocused",
+	"client.urgent",
+	"default_border",
+	"default_floating_border",
+	"default_orientation",
+	"floating_modifier",
+	"focus_follows_mouse",
+	"focus_on_window_activation",
+	"focus_wrapping",
+	"font",
+	"force_display_urgency_hint",
+	"force_focus_wrapping",
+	"hide_edge_borders",
+	"ipc_kill_timeout",
+	"mouse_warping",
+	"new_float",
+	"new_window",
+	"no_focus",
+	"popup_during_fullscreen",
+	"show_marks",
+	"smart_borders",
+	"tiling_drag",
+	"title_align",
+	"workspace_layout",
+}
+
+// Directive is a directive that doesn't have a more specific node. Directives
+// ending in { hold a block of further directives in Children.
+type Directive struct {
+	*parser.Section
+	Name     *Word
+	Value    *Text
+	Children []parser.Node
+}
+
+// DirectiveParser parses a directive with one of names, or any directive if
+// no names are given.
+func DirectiveParser(names ...string) parser.Parser {
+	return func(parent parser.Node, block *parser.Reader) (parser.Node, error) {
+		tx := block.BeginTx()
+		defer tx.Rollback()
+
+		if !isIdentifierCharacter(block.Peak()) {
+			return nil, parser.ErrWrongParser
+		}
+		if len(names) > 0 && !slices.Contains(names, string(block.PeakWord())) {
+			return nil, parser.ErrWrongParser
+		}
+
+		d := &Directive{}
+
+		name, err := ParseWord(d, block)
+		if err != nil {
+			return nil, err
+		}
+		d.Name = name.(*Word)
+
+		skipInlineWhitespace(block)
+
+		if block.Peak() == '{' {
+			d.Children, err = parseBlock(d, block, ParseWhitespace, ParseComment, DirectiveParser())
+			if err != nil {
+				return nil, err
+			}
+		} else if !isLineEnd(block.Peak()) {
+			value, err := ParseText(d, block)
+			if err != nil {
+				return nil, err
+			}
+			d.Value = value.(*Text)
+		}
+
+		d.Section = tx.Commit()
+		return d, nil
+	}
+}
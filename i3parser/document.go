@@ -30,12 +30,23 @@ func (d *Document) Children() []parser.Node {
 }
 
 var docChildren = []parser.Parser{
+	ParseWhitespace,
+	ParseComment,
 	ParseVariable,
 	ParseGaps,
+	ParseFloatingSize,
 	ParseSmartGaps,
+	ParseAutoBackAndForth,
+	ParseKillTimeout,
 	ParseForWindow,
-	ParseWhitespace,
-	ParseCommand,
+	ParseAssign,
+	ParseBindSym,
+	ParseMode,
+	ParseWorkspace,
+	ParseExec,
+	ParseBar,
+	ParseInclude,
+	DirectiveParser(ignoredDirectives...),
 }
 
 func ParseDocument(parent parser.Node, block *parser.Reader) (parser.Node, error) {
@@ -0,0 +1,214 @@
+package i3parser
+
+import (
+	"testing"
+
+	"github.com/abibby/yabai3/parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func parseString(src string) (*Document, error) {
+	d, err := ParseDocument(nil, parser.NewReader("config", []byte(src)))
+	if err != nil {
+		return nil, err
+	}
+	return d.(*Document), nil
+}
+
+func TestParseDocument(t *testing.T) {
+	src := `# comment
+set $mod Mod1
+set $mod2 Mod4
+set $ws1 "1: web"
+font pango:monospace 8
+
+bindsym $mod2+Return exec --no-startup-id kitty -e tmux
+bindsym $mod+Shift+1 move container to workspace $ws1; workspace $ws1
+workspace $ws1 output 2 1
+gaps inner 10
+exec_always --no-startup-id ~/bin/start
+for_window [class="Firefox" floating] border pixel 2, fullscreen toggle
+
+mode "resize" {
+	bindsym escape mode "default"
+}
+
+bar {
+	status_command i3status
+	colors {
+		background #000000
+	}
+}
+`
+	d, err := parseString(src)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	nodes := []parser.Node{}
+	for _, c := range d.Children() {
+		switch c.(type) {
+		case *Whitespace, *Comment, *Variable:
+		default:
+			nodes = append(nodes, c)
+		}
+	}
+	if !assert.Len(t, nodes, 9) {
+		return
+	}
+
+	exec := nodes[1].(*BindSym)
+	assert.Equal(t, "Mod4+Return", exec.Keys.Value)
+	assert.Equal(t, []string{"exec", "--no-startup-id", "kitty -e tmux"}, exec.Commands.List()[0].Args())
+
+	move := nodes[2].(*BindSym)
+	assert.Equal(t, "Mod1+Shift+1", move.Keys.Value)
+	if assert.Len(t, move.Commands.List(), 2) {
+		assert.Equal(t, []string{"move", "container", "to", "workspace", "1: web"}, move.Commands.List()[0].Args())
+		assert.Equal(t, []string{"workspace", "1: web"}, move.Commands.List()[1].Args())
+	}
+
+	workspace := nodes[3].(*Workspace)
+	assert.Equal(t, "1: web", workspace.Name.Value)
+	assert.Len(t, workspace.Outputs, 2)
+
+	assert.Equal(t, 10.0, nodes[4].(*Gaps).With.Value)
+
+	execAlways := nodes[5].(*Exec)
+	assert.True(t, execAlways.Always())
+	assert.Equal(t, "~/bin/start", execAlways.Command.Value)
+
+	forWindow := nodes[6].(*ForWindow)
+	if assert.Len(t, forWindow.Conditions.Conditions, 2) {
+		assert.Equal(t, "Firefox", forWindow.Conditions.Conditions[0].Value.Value)
+		assert.Nil(t, forWindow.Conditions.Conditions[1].Value)
+	}
+	assert.Equal(t, []string{"border", "pixel", "2"}, forWindow.Commands.List()[0].Args())
+	assert.Equal(t, []string{"fullscreen", "toggle"}, forWindow.Commands.List()[1].Args())
+
+	mode := nodes[7].(*Mode)
+	assert.Equal(t, "resize", mode.Name.Value)
+	if assert.Len(t, mode.BindSyms(), 1) {
+		assert.Equal(t, []string{"mode", "default"}, mode.BindSyms()[0].Commands.List()[0].Args())
+	}
+
+	assert.Equal(t, "i3status", nodes[8].(*Bar).StatusCommand().Command.Value)
+}
+
+func TestParseDocument_errors(t *testing.T) {
+	testCases := []struct {
+		src      string
+		expected string
+	}{
+		{
+			src:      "gaps inner 10\ngaps middle 10\n",
+			expected: "config:2:6: invalid gap type middle, must be one of inner, outer, horizontal, vertical, top, right, bottom, left",
+		},
+		{
+			src:      "gaps outer ten\n",
+			expected: "config:1:12: expected [0-9.] received t",
+		},
+		{
+			src:      "bindsym Mod1+a\n",
+			expected: "config:1:15: expected a command",
+		},
+		{
+			src:      "mode \"resize\" {\n\tbindsym escape mode default\n",
+			expected: "config:3:1: expected }",
+		},
+		{
+			src:      "floating_modifier Mod1\nfocus_follows_mice no\n",
+			expected: "config:2:1: unexpected content \"focus_follows_mice no\"",
+		},
+		{
+			src:      "bindsym Mod1+a workspace \"1: web\n",
+			expected: "config:1:33: unterminated string",
+		},
+		{
+			src:      "assign [class=\"Slack\"]\n",
+			expected: "config:1:23: expected a workspace or output",
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.expected, func(t *testing.T) {
+			_, err := parseString(tc.src)
+			assert.EqualError(t, err, tc.expected)
+		})
+	}
+}
+
+func TestParseDocument_i3Compatible(t *testing.T) {
+	d, err := parseString(`set_from_resource $bg i3wm.background #000000
+gaps horizontal 5
+gaps top 10
+workspace 1 gaps inner 5
+bindsym Mod1+a exec echo $bg
+`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	nodes := []parser.Node{}
+	for _, c := range d.Children() {
+		if _, ok := c.(*Whitespace); !ok {
+			nodes = append(nodes, c)
+		}
+	}
+	if !assert.Len(t, nodes, 5) {
+		return
+	}
+	variable := nodes[0].(*Variable)
+	assert.Equal(t, "i3wm.background", variable.Resource.Value)
+	assert.Equal(t, "#000000", variable.Value.Value)
+	assert.Equal(t, "horizontal", nodes[1].(*Gaps).Type.Value)
+	workspace := nodes[3].(*Workspace)
+	assert.Nil(t, workspace.Output)
+	assert.Equal(t, "inner", workspace.Gaps.Type.Value)
+	assert.Equal(t, []string{"exec", "echo #000000"}, nodes[4].(*BindSym).Commands.List()[0].Args())
+}
+
+func TestParseDocument_assign(t *testing.T) {
+	d, err := parseString(`set $chat "8: chat"
+assign [class="Slack"] 8
+assign [class="Discord"] → workspace $chat
+assign [class="Mail"] number 3: mail
+assign [title="Zoom"] output right
+`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assigns := []*Assign{}
+	for _, c := range d.Children() {
+		if a, ok := c.(*Assign); ok {
+			assigns = append(assigns, a)
+		}
+	}
+	if !assert.Len(t, assigns, 4) {
+		return
+	}
+
+	assert.Equal(t, "Slack", assigns[0].Conditions.Conditions[0].Value.Value)
+	assert.Equal(t, "8", assigns[0].Target.Value)
+	assert.Nil(t, assigns[0].Output)
+
+	assert.NotNil(t, assigns[1].Workspace)
+	assert.Equal(t, "8: chat", assigns[1].Target.Value)
+
+	assert.NotNil(t, assigns[2].Number)
+	assert.Equal(t, "3: mail", assigns[2].Target.Value)
+
+	assert.NotNil(t, assigns[3].Output)
+	assert.Equal(t, "right", assigns[3].Target.Value)
+}
+
+func TestParseCommandString(t *testing.T) {
+	c, err := ParseCommandString(`[con_mark="a"] focus, kill; exec "echo a; echo b"`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	if assert.Len(t, c.Chains, 2) {
+		assert.Equal(t, "con_mark", c.Chains[0].Conditions.Conditions[0].Type.Value)
+		assert.Len(t, c.Chains[0].Commands, 2)
+		assert.Nil(t, c.Chains[1].Conditions)
+		assert.Equal(t, []string{"exec", "echo a; echo b"}, c.Chains[1].Commands[0].Args())
+	}
+}
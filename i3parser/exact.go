@@ -18,6 +18,8 @@ func NewExact(s *parser.Section, value string) *Exact {
 	}
 }
 
+// ExactParser matches value. Values ending in a word character must be
+// followed by a word boundary so exec never matches the start of exec_always.
 func ExactParser(value string) parser.Parser {
 	return func(parent parser.Node, block *parser.Reader) (parser.Node, error) {
 		tx := block.BeginTx()
@@ -27,7 +29,14 @@ func ExactParser(value string) parser.Parser {
 		if string(b) != value {
 			return nil, fmt.Errorf("expected %s received %s", value, b)
 		}
+		if isWordCharacter(value[len(value)-1]) && isWordCharacter(block.Peak()) {
+			return nil, fmt.Errorf("expected %s received %s%s", value, b, block.PeakWord())
+		}
 
 		return NewExact(tx.Commit(), value), nil
 	}
 }
+
+func isWordCharacter(b byte) bool {
+	return isIdentifierCharacter(b) || b == '-' || b == '.'
+}
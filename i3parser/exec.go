@@ -0,0 +1,129 @@
+package i3parser
+
+import (
+	"bytes"
+	"strings"
+
+	"github.com/abibby/yabai3/parser"
+)
+
+var KindExec = CommandKind("exec")
+
+// Exec is the exec and exec_always directive, the command is the rest of the
+// line and is passed to the shell as is.
+type Exec struct {
+	*parser.Section
+	Exec    *Exact
+	Flags   []*Word
+	Command *Text
+}
+
+func (e *Exec) Always() bool {
+	return e.Exec.Value == "exec_always"
+}
+
+func ParseExec(parent parser.Node, block *parser.Reader) (parser.Node, error) {
+	tx := block.BeginTx()
+	defer tx.Rollback()
+
+	e := &Exec{}
+
+	exec, err := ExactParser("exec_always")(e, block)
+	if err != nil {
+		exec, err = ExactParser("exec")(e, block)
+	}
+	if err != nil {
+		return nil, parser.ErrWrongParser
+	}
+	e.Exec = exec.(*Exact)
+
+	e.Flags, err = parseFlags(e, block)
+	if err != nil {
+		return nil, err
+	}
+
+	command, err := ParseText(e, block)
+	if err != nil {
+		return nil, err
+	}
+	e.Command = command.(*Text)
+
+	e.Section = tx.Commit()
+	return e, nil
+}
+
+// ExecCommand is the exec command. Unlike the exec directive the command ends
+// at a ; or , unless it is quoted.
+type ExecCommand struct {
+	*parser.Section
+	Exec    *Exact
+	Flags   []*Word
+	Command *Word
+}
+
+func (e *ExecCommand) Kind() CommandKind {
+	return KindExec
+}
+
+func (e *ExecCommand) Args() []string {
+	args := []string{string(KindExec)}
+	for _, f := range e.Flags {
+		args = append(args, f.Value)
+	}
+	return append(args, e.Command.Value)
+}
+
+func ParseExecCommand(parent parser.Node, block *parser.Reader) (parser.Node, error) {
+	tx := block.BeginTx()
+	defer tx.Rollback()
+
+	e := &ExecCommand{}
+
+	exec, err := ExactParser("exec")(e, block)
+	if err != nil {
+		return nil, parser.ErrWrongParser
+	}
+	e.Exec = exec.(*Exact)
+
+	e.Flags, err = parseFlags(e, block)
+	if err != nil {
+		return nil, err
+	}
+
+	b := block.Peak()
+	if b == '"' || b == '\'' {
+		command, err := ParseWord(e, block)
+		if err != nil {
+			return nil, err
+		}
+		e.Command = command.(*Word)
+	} else {
+		commandTx := block.BeginTx()
+		command := strings.TrimSpace(string(block.ReadUntil([]byte(";,\n"))))
+		if command == "" {
+			commandTx.Rollback()
+			return nil, parser.NewError(block, errExpectedCommand)
+		}
+		e.Command = NewWord(commandTx.Commit(), block.Expand(command))
+	}
+
+	e.Section = tx.Commit()
+	return e, nil
+}
+
+// parseFlags parses options starting with -- like --no-startup-id and
+// --release.
+func parseFlags(parent parser.Node, block *parser.Reader) ([]*Word, error) {
+	flags := []*Word{}
+	for {
+		skipInlineWhitespace(block)
+		if !bytes.HasPrefix(block.PeakWord(), []byte("--")) {
+			return flags, nil
+		}
+		flag, err := ParseWord(parent, block)
+		if err != nil {
+			return nil, err
+		}
+		flags = append(flags, flag.(*Word))
+	}
+}
@@ -0,0 +1,66 @@
+package i3parser
+
+import (
+	"fmt"
+
+	"github.com/abibby/yabai3/parser"
+)
+
+// FloatingSize is a floating_minimum_size or floating_maximum_size directive,
+// written as width x height.
+type FloatingSize struct {
+	*parser.Section
+	Name   *Exact
+	Width  *Number
+	Height *Number
+}
+
+// Minimum reports whether this is a floating_minimum_size directive.
+func (f *FloatingSize) Minimum() bool {
+	return f.Name.Value == "floating_minimum_size"
+}
+
+func ParseFloatingSize(parent parser.Node, block *parser.Reader) (parser.Node, error) {
+	tx := block.BeginTx()
+	defer tx.Rollback()
+
+	f := &FloatingSize{}
+
+	name, err := ExactParser("floating_minimum_size")(f, block)
+	if err != nil {
+		name, err = ExactParser("floating_maximum_size")(f, block)
+		if err != nil {
+			return nil, parser.ErrWrongParser
+		}
+	}
+	f.Name = name.(*Exact)
+
+	skipInlineWhitespace(block)
+
+	width, err := ParseNumber(f, block)
+	if err != nil {
+		return nil, err
+	}
+	f.Width = width.(*Number)
+
+	skipInlineWhitespace(block)
+	if block.Peak() != 'x' {
+		return nil, parser.NewError(block, fmt.Errorf("expected x received %c", block.Peak()))
+	}
+	block.Advance(1)
+	skipInlineWhitespace(block)
+
+	height, err := ParseNumber(f, block)
+	if err != nil {
+		return nil, err
+	}
+	f.Height = height.(*Number)
+
+	err = expectLineEnd(block)
+	if err != nil {
+		return nil, err
+	}
+
+	f.Section = tx.Commit()
+	return f, nil
+}
@@ -8,7 +8,7 @@ type ForWindow struct {
 	*parser.Section
 	ForWindow  *Exact
 	Conditions *Conditions
-	Command    Command
+	Commands   *Commands
 }
 
 func NewForWindow(s *parser.Section) *ForWindow {
@@ -21,7 +21,7 @@ func ParseForWindow(parent parser.Node, block *parser.Reader) (parser.Node, erro
 	tx := block.BeginTx()
 	defer tx.Rollback()
 
-	w := NewForWindow(tx.Commit())
+	w := NewForWindow(nil)
 
 	forWindow, err := ExactParser("for_window")(w, block)
 	if err != nil {
@@ -29,21 +29,27 @@ func ParseForWindow(parent parser.Node, block *parser.Reader) (parser.Node, erro
 	}
 	w.ForWindow = forWindow.(*Exact)
 
-	block.SkipWhitespace()
+	skipInlineWhitespace(block)
 
-	conditions, err := ParseConditions(parent, block)
+	conditions, err := ParseConditions(w, block)
 	if err != nil {
 		return nil, err
 	}
 	w.Conditions = conditions.(*Conditions)
 
-	block.SkipWhitespace()
+	skipInlineWhitespace(block)
 
-	command, err := ParseCommand(parent, block)
+	commands, err := ParseCommands(w, block)
 	if err != nil {
 		return nil, err
 	}
-	w.Command = command.(Command)
+	w.Commands = commands.(*Commands)
 
+	err = expectLineEnd(block)
+	if err != nil {
+		return nil, err
+	}
+
+	w.Section = tx.Commit()
 	return w, nil
 }
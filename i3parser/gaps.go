@@ -2,10 +2,16 @@ package i3parser
 
 import (
 	"fmt"
+	"slices"
+	"strings"
 
 	"github.com/abibby/yabai3/parser"
 )
 
+// gapTypes are the gaps i3 accepts. yabai only has one padding for every
+// edge of a space so only inner and outer have an effect.
+var gapTypes = []string{"inner", "outer", "horizontal", "vertical", "top", "right", "bottom", "left"}
+
 type Gaps struct {
 	*parser.Section
 	Gaps *Exact
@@ -23,7 +29,7 @@ func ParseGaps(parent parser.Node, block *parser.Reader) (parser.Node, error) {
 	tx := block.BeginTx()
 	defer tx.Rollback()
 
-	g := NewGaps(tx.Commit())
+	g := NewGaps(nil)
 
 	gaps, err := ExactParser("gaps")(g, block)
 	if err != nil {
@@ -31,7 +37,7 @@ func ParseGaps(parent parser.Node, block *parser.Reader) (parser.Node, error) {
 	}
 	g.Gaps = gaps.(*Exact)
 
-	block.SkipWhitespace()
+	skipInlineWhitespace(block)
 
 	gapType, err := ParseIdentifier(g, block)
 	if err != nil {
@@ -39,7 +45,11 @@ func ParseGaps(parent parser.Node, block *parser.Reader) (parser.Node, error) {
 	}
 	g.Type = gapType.(*Identifier)
 
-	block.SkipWhitespace()
+	if !slices.Contains(gapTypes, g.Type.Value) {
+		return nil, parser.NewNodeError(block, g.Type, fmt.Errorf("invalid gap type %s, must be one of %s", g.Type.Value, strings.Join(gapTypes, ", ")))
+	}
+
+	skipInlineWhitespace(block)
 
 	width, err := ParseNumber(g, block)
 	if err != nil {
@@ -47,9 +57,11 @@ func ParseGaps(parent parser.Node, block *parser.Reader) (parser.Node, error) {
 	}
 	g.With = width.(*Number)
 
-	if g.Type.Value != "inner" && g.Type.Value != "outer" {
-		return nil, fmt.Errorf("invalid gap type %s, must be inner or outer", g.Type.Value)
+	err = expectLineEnd(block)
+	if err != nil {
+		return nil, err
 	}
 
+	g.Section = tx.Commit()
 	return g, nil
 }
@@ -33,5 +33,5 @@ func ParseIdentifier(parent parser.Node, block *parser.Reader) (parser.Node, err
 }
 
 func isIdentifierCharacter(b byte) bool {
-	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9')
+	return (b >= 'a' && b <= 'z') || (b >= 'A' && b <= 'Z') || (b >= '0' && b <= '9') || b == '_'
 }
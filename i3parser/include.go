@@ -0,0 +1,35 @@
+package i3parser
+
+import (
+	"github.com/abibby/yabai3/parser"
+)
+
+type Include struct {
+	*parser.Section
+	Include *Exact
+	Path    *Text
+}
+
+func ParseInclude(parent parser.Node, block *parser.Reader) (parser.Node, error) {
+	tx := block.BeginTx()
+	defer tx.Rollback()
+
+	i := &Include{}
+
+	include, err := ExactParser("include")(i, block)
+	if err != nil {
+		return nil, parser.ErrWrongParser
+	}
+	i.Include = include.(*Exact)
+
+	skipInlineWhitespace(block)
+
+	path, err := ParseText(i, block)
+	if err != nil {
+		return nil, err
+	}
+	i.Path = path.(*Text)
+
+	i.Section = tx.Commit()
+	return i, nil
+}
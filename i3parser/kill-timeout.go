@@ -0,0 +1,42 @@
+package i3parser
+
+import (
+	"github.com/abibby/yabai3/parser"
+)
+
+// KillTimeout is a kill_timeout directive, the milliseconds `kill client`
+// waits for an application to quit before killing it. It isn't part of i3.
+type KillTimeout struct {
+	*parser.Section
+	KillTimeout *Exact
+	Value       *Number
+}
+
+func ParseKillTimeout(parent parser.Node, block *parser.Reader) (parser.Node, error) {
+	tx := block.BeginTx()
+	defer tx.Rollback()
+
+	k := &KillTimeout{}
+
+	name, err := ExactParser("kill_timeout")(k, block)
+	if err != nil {
+		return nil, parser.ErrWrongParser
+	}
+	k.KillTimeout = name.(*Exact)
+
+	skipInlineWhitespace(block)
+
+	value, err := ParseNumber(k, block)
+	if err != nil {
+		return nil, err
+	}
+	k.Value = value.(*Number)
+
+	err = expectLineEnd(block)
+	if err != nil {
+		return nil, err
+	}
+
+	k.Section = tx.Commit()
+	return k, nil
+}
@@ -0,0 +1,62 @@
+package i3parser
+
+import (
+	"github.com/abibby/yabai3/parser"
+)
+
+type Mode struct {
+	*parser.Section
+	Mode     *Exact
+	Flags    []*Word
+	Name     *Word
+	Children []parser.Node
+}
+
+func (m *Mode) BindSyms() []*BindSym {
+	bindSyms := []*BindSym{}
+	for _, c := range m.Children {
+		if b, ok := c.(*BindSym); ok {
+			bindSyms = append(bindSyms, b)
+		}
+	}
+	return bindSyms
+}
+
+var modeChildren = []parser.Parser{
+	ParseWhitespace,
+	ParseComment,
+	ParseBindSym,
+	DirectiveParser("bindcode"),
+}
+
+func ParseMode(parent parser.Node, block *parser.Reader) (parser.Node, error) {
+	tx := block.BeginTx()
+	defer tx.Rollback()
+
+	m := &Mode{}
+
+	mode, err := ExactParser("mode")(m, block)
+	if err != nil {
+		return nil, parser.ErrWrongParser
+	}
+	m.Mode = mode.(*Exact)
+
+	m.Flags, err = parseFlags(m, block)
+	if err != nil {
+		return nil, err
+	}
+
+	name, err := ParseWord(m, block)
+	if err != nil {
+		return nil, err
+	}
+	m.Name = name.(*Word)
+
+	m.Children, err = parseBlock(m, block, modeChildren...)
+	if err != nil {
+		return nil, err
+	}
+
+	m.Section = tx.Commit()
+	return m, nil
+}
@@ -25,19 +25,24 @@ func ParseNumber(parent parser.Node, block *parser.Reader) (parser.Node, error)
 
 	result := ""
 	b := block.Peak()
-	for (b >= '0' && b <= '9') || (b == '.' && result != "") {
+	if b == '-' {
+		block.Advance(1)
+		result += "-"
+		b = block.Peak()
+	}
+	for (b >= '0' && b <= '9') || (b == '.' && result != "" && result != "-") {
 		block.Advance(1)
 		result += string(b)
 		b = block.Peak()
 	}
 
-	if !isWhitespace(b) || result == "" {
-		return nil, fmt.Errorf("expected [0-9.] received %c", b)
+	if !isWordEnd(b) || result == "" || result == "-" {
+		return nil, parser.NewError(block, fmt.Errorf("expected [0-9.] received %c", b))
 	}
 
 	value, err := strconv.ParseFloat(result, 64)
 	if err != nil {
-		return nil, err
+		return nil, parser.NewError(block, err)
 	}
 	return NewNumber(tx.Commit(), value), nil
 }
@@ -1,6 +1,8 @@
 package i3parser
 
 import (
+	"fmt"
+
 	"github.com/abibby/yabai3/parser"
 )
 
@@ -11,3 +13,18 @@ func Parse(file string) (parser.Node, error) {
 	}
 	return ParseDocument(nil, r)
 }
+
+// ParseCommandString parses commands sent by IPC clients.
+func ParseCommandString(src string) (*Commands, error) {
+	r := parser.NewReader("command", []byte(src))
+	r.SkipWhitespace()
+	c, err := ParseCommands(nil, r)
+	if err != nil {
+		return nil, err
+	}
+	r.SkipWhitespace()
+	if r.Peak() != parser.EOF {
+		return nil, parser.NewError(r, fmt.Errorf("unexpected content \"%s\"", r.PeakLine()))
+	}
+	return c.(*Commands), nil
+}
@@ -20,7 +20,7 @@ func ParseSmartGaps(parent parser.Node, block *parser.Reader) (parser.Node, erro
 	tx := block.BeginTx()
 	defer tx.Rollback()
 
-	s := NewSmartGaps(tx.Commit())
+	s := NewSmartGaps(nil)
 
 	smartGaps, err := ExactParser("smart_gaps")(s, block)
 	if err != nil {
@@ -28,7 +28,7 @@ func ParseSmartGaps(parent parser.Node, block *parser.Reader) (parser.Node, erro
 	}
 	s.SmartGaps = smartGaps.(*Exact)
 
-	block.SkipWhitespace()
+	skipInlineWhitespace(block)
 
 	value, err := ParseBool(s, block)
 	if err != nil {
@@ -36,5 +36,11 @@ func ParseSmartGaps(parent parser.Node, block *parser.Reader) (parser.Node, erro
 	}
 	s.Value = value.(*Bool)
 
+	err = expectLineEnd(block)
+	if err != nil {
+		return nil, err
+	}
+
+	s.Section = tx.Commit()
 	return s, nil
 }
@@ -18,30 +18,33 @@ func NewString(s *parser.Section, value string) *String {
 	}
 }
 
+// ParseString parses a single or double quoted string. Quotes and
+// backslashes can be escaped with a backslash.
 func ParseString(parent parser.Node, block *parser.Reader) (parser.Node, error) {
 	tx := block.BeginTx()
 	defer tx.Rollback()
 
-	b := block.ReadNextByte()
-	if b != '"' {
-		return nil, parser.NewError(block, fmt.Errorf("expected \" recieved %c", b))
+	b := block.Peak()
+	if b != '"' && b != '\'' {
+		return nil, parser.NewError(block, fmt.Errorf("expected \" received %c", b))
 	}
+	block.Advance(1)
 
-	result := ""
+	result := []byte{}
 	quote := b
-	escape := false
 	for {
-		b = block.ReadNextByte()
-		if escape {
-			result += string(b)
+		b = block.Peak()
+		if isLineEnd(b) {
+			return nil, parser.NewError(block, fmt.Errorf("unterminated string"))
 		}
-		if b == '\\' {
-			escape = true
+		block.Advance(1)
+		if b == '\\' && (block.Peak() == quote || block.Peak() == '\\') {
+			result = append(result, block.ReadNextByte())
 			continue
 		}
 		if b == quote {
-			return NewString(tx.Commit(), result), nil
+			return NewString(tx.Commit(), block.Expand(string(result))), nil
 		}
-		result += string(b)
+		result = append(result, b)
 	}
 }
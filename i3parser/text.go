@@ -0,0 +1,33 @@
+package i3parser
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/abibby/yabai3/parser"
+)
+
+// Text is the rest of a line, used for values like shell commands that are
+// passed on as is.
+type Text struct {
+	*parser.Section
+	Value string
+}
+
+func NewText(s *parser.Section, value string) *Text {
+	return &Text{
+		Section: s,
+		Value:   value,
+	}
+}
+
+func ParseText(parent parser.Node, block *parser.Reader) (parser.Node, error) {
+	tx := block.BeginTx()
+	defer tx.Rollback()
+
+	value := strings.TrimSpace(string(block.ReadUntil([]byte("\n"))))
+	if value == "" {
+		return nil, parser.NewError(block, fmt.Errorf("expected a value"))
+	}
+	return NewText(tx.Commit(), block.Expand(value)), nil
+}
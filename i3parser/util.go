@@ -1,11 +1,37 @@
 package i3parser
 
+import (
+	"fmt"
+
+	"github.com/abibby/yabai3/parser"
+)
+
 func isWhitespace(b byte) bool {
 	return isInlineWhitespace(b) || b == '\n'
 }
 
 func isInlineWhitespace(b byte) bool {
-	return b == ' ' || b == '\t'
+	return b == ' ' || b == '\t' || b == '\r'
+}
+
+func isLineEnd(b byte) bool {
+	return b == '\n' || b == parser.EOF
+}
+
+func skipInlineWhitespace(block *parser.Reader) {
+	for isInlineWhitespace(block.Peak()) {
+		block.Advance(1)
+	}
+}
+
+// expectLineEnd returns an error if anything other than whitespace is left on
+// the current line.
+func expectLineEnd(block *parser.Reader) error {
+	skipInlineWhitespace(block)
+	if !isLineEnd(block.Peak()) {
+		return parser.NewError(block, fmt.Errorf("unexpected content \"%s\"", block.PeakLine()))
+	}
+	return nil
 }
 
 // func ParseVariableName(block *parser.Reader) (string, error) {
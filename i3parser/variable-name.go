@@ -22,13 +22,14 @@ func ParseVariableName(parent parser.Node, block *parser.Reader) (parser.Node, e
 	tx := block.BeginTx()
 	defer tx.Rollback()
 
-	word := block.ReadWord()
+	word := block.PeakWord()
 	if len(word) == 0 {
-		return nil, fmt.Errorf("could not find variable name")
+		return nil, parser.NewError(block, fmt.Errorf("could not find variable name"))
 	}
 	if word[0] != '$' {
-		return nil, fmt.Errorf("invalid variable name %s: must start with $", word)
+		return nil, parser.NewError(block, fmt.Errorf("invalid variable name %s: must start with $", word))
 	}
+	block.Advance(len(word))
 
 	return NewVariableName(tx.Commit(), string(word)), nil
 }
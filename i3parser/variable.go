@@ -4,9 +4,10 @@ import "github.com/abibby/yabai3/parser"
 
 type Variable struct {
 	*parser.Section
-	Set   *Exact
-	Name  *VariableName
-	Value *Identifier
+	Set      *Exact
+	Name     *VariableName
+	Resource *Word
+	Value    *Text
 }
 
 func NewVariable(s *parser.Section) *Variable {
@@ -15,18 +16,25 @@ func NewVariable(s *parser.Section) *Variable {
 	}
 }
 
+// ParseVariable parses `set $name value` and `set_from_resource $name
+// resource fallback`. There are no X resources on macOS so the fallback is
+// always used. The variable is expanded in every line that follows it,
+// including lines in included files.
 func ParseVariable(parent parser.Node, block *parser.Reader) (parser.Node, error) {
 	tx := block.BeginTx()
 	defer tx.Rollback()
 
-	v := NewVariable(tx.Commit())
-	set, err := ExactParser("set")(v, block)
+	v := NewVariable(nil)
+	set, err := ExactParser("set_from_resource")(v, block)
+	if err != nil {
+		set, err = ExactParser("set")(v, block)
+	}
 	if err != nil {
 		return nil, parser.ErrWrongParser
 	}
 	v.Set = set.(*Exact)
 
-	block.SkipWhitespace()
+	skipInlineWhitespace(block)
 
 	name, err := ParseVariableName(v, block)
 	if err != nil {
@@ -34,13 +42,26 @@ func ParseVariable(parent parser.Node, block *parser.Reader) (parser.Node, error
 	}
 	v.Name = name.(*VariableName)
 
-	block.SkipWhitespace()
+	skipInlineWhitespace(block)
+
+	if v.Set.Value == "set_from_resource" {
+		resource, err := ParseWord(v, block)
+		if err != nil {
+			return nil, err
+		}
+		v.Resource = resource.(*Word)
 
-	value, err := ParseIdentifier(v, block)
+		skipInlineWhitespace(block)
+	}
+
+	value, err := ParseText(v, block)
 	if err != nil {
 		return nil, err
 	}
-	v.Value = value.(*Identifier)
+	v.Value = value.(*Text)
+
+	block.SetVariable(v.Name.Value, v.Value.Value)
 
+	v.Section = tx.Commit()
 	return v, nil
 }
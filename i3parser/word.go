@@ -0,0 +1,104 @@
+package i3parser
+
+import (
+	"errors"
+	"fmt"
+	"strings"
+
+	"github.com/abibby/yabai3/parser"
+)
+
+type Word struct {
+	*parser.Section
+	Value string
+}
+
+func NewWord(s *parser.Section, value string) *Word {
+	return &Word{
+		Section: s,
+		Value:   value,
+	}
+}
+
+// ParseWord parses a quoted string or a bare word. Bare words end at
+// whitespace, ; or , and have their variables expanded.
+func ParseWord(parent parser.Node, block *parser.Reader) (parser.Node, error) {
+	words, err := parseWord(parent, block)
+	if err != nil {
+		return nil, err
+	}
+	if len(words) != 1 {
+		return nil, parser.NewNodeError(block, words[0], fmt.Errorf("expected a single word"))
+	}
+	return words[0], nil
+}
+
+// parseWords parses words until the end of the line or a ; or ,.
+func parseWords(parent parser.Node, block *parser.Reader) ([]*Word, error) {
+	words := []*Word{}
+	for {
+		skipInlineWhitespace(block)
+		b := block.Peak()
+		if isLineEnd(b) || b == ';' || b == ',' {
+			return words, nil
+		}
+
+		w, err := parseWord(parent, block)
+		if err != nil {
+			return nil, err
+		}
+		words = append(words, w...)
+	}
+}
+
+// parseWord parses a single word from the source. A variable that expands to
+// several words is split the same way i3 would after substituting it into the
+// line.
+func parseWord(parent parser.Node, block *parser.Reader) ([]*Word, error) {
+	tx := block.BeginTx()
+	defer tx.Rollback()
+
+	b := block.Peak()
+	if b == '"' || b == '\'' {
+		s, err := ParseString(parent, block)
+		if err != nil {
+			return nil, err
+		}
+		return []*Word{NewWord(tx.Commit(), s.(*String).Value)}, nil
+	}
+
+	raw := string(block.ReadUntil([]byte(" \t\r\n;,")))
+	if len(raw) == 0 {
+		return nil, parser.NewError(block, fmt.Errorf("expected a word"))
+	}
+	w := NewWord(tx.Commit(), block.Expand(raw))
+	if w.Value == raw || !strings.ContainsAny(w.Value, " \t\"'") {
+		return []*Word{w}, nil
+	}
+	return splitWord(parent, block, w)
+}
+
+func isWordEnd(b byte) bool {
+	return isWhitespace(b) || b == ';' || b == ',' || b == parser.EOF
+}
+
+func splitWord(parent parser.Node, block *parser.Reader, w *Word) ([]*Word, error) {
+	r := parser.NewReader(block.File(), []byte(w.Value))
+	words, err := parseWords(parent, r)
+	if err == nil && r.Peak() != parser.EOF {
+		err = fmt.Errorf("unexpected %c in variable", r.Peak())
+	} else if err == nil && len(words) == 0 {
+		err = fmt.Errorf("expected a word")
+	}
+	if err != nil {
+		var parseErr *parser.Error
+		if errors.As(err, &parseErr) {
+			err = parseErr.Unwrap()
+		}
+		return nil, parser.NewNodeError(block, w, err)
+	}
+	for _, sw := range words {
+		sw.Section = w.Section
+	}
+	return words, nil
+}
@@ -0,0 +1,74 @@
+package i3parser
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/abibby/yabai3/parser"
+)
+
+// Workspace assigns a workspace to the first of its outputs that is
+// connected. `workspace <name> gaps <type> <px>` is accepted as well, yabai
+// doesn't have gaps per space so it has no effect.
+type Workspace struct {
+	*parser.Section
+	Workspace *Exact
+	Name      *Word
+	Output    *Exact
+	Outputs   []*Word
+	Gaps      *Gaps
+}
+
+func ParseWorkspace(parent parser.Node, block *parser.Reader) (parser.Node, error) {
+	tx := block.BeginTx()
+	defer tx.Rollback()
+
+	w := &Workspace{}
+
+	workspace, err := ExactParser("workspace")(w, block)
+	if err != nil {
+		return nil, parser.ErrWrongParser
+	}
+	w.Workspace = workspace.(*Exact)
+
+	skipInlineWhitespace(block)
+
+	name, err := ParseWord(w, block)
+	if err != nil {
+		return nil, err
+	}
+	w.Name = name.(*Word)
+
+	skipInlineWhitespace(block)
+
+	gaps, err := ParseGaps(w, block)
+	if err == nil {
+		w.Gaps = gaps.(*Gaps)
+		w.Section = tx.Commit()
+		return w, nil
+	} else if !errors.Is(err, parser.ErrWrongParser) {
+		return nil, err
+	}
+
+	output, err := ExactParser("output")(w, block)
+	if err != nil {
+		return nil, parser.NewError(block, fmt.Errorf("expected output received %s", block.PeakWord()))
+	}
+	w.Output = output.(*Exact)
+
+	w.Outputs, err = parseWords(w, block)
+	if err != nil {
+		return nil, err
+	}
+	if len(w.Outputs) == 0 {
+		return nil, parser.NewError(block, fmt.Errorf("expected an output"))
+	}
+
+	err = expectLineEnd(block)
+	if err != nil {
+		return nil, err
+	}
+
+	w.Section = tx.Commit()
+	return w, nil
+}
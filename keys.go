@@ -1,7 +1,7 @@
 package main
 
 import (
-	"log"
+	"fmt"
 	"strings"
 
 	"golang.design/x/hotkey"
@@ -93,32 +93,49 @@ var keyMap = map[string]hotkey.Key{
 	"grave":  50,
 }
 
-func keys(keysStr string) ([]hotkey.Modifier, hotkey.Key) {
+// keys converts a key binding like Mod1+Shift+a to the modifiers and key
+// hotkey expects.
+func keys(keysStr string) ([]hotkey.Modifier, hotkey.Key, error) {
 	mods := []hotkey.Modifier{}
 	key := hotkey.Key(0)
+	hasKey := false
 
 	keys := strings.Split(keysStr, "+")
 	modb := hotkey.Modifier(0)
 	for _, k := range keys {
-		found := false
 		if mod, ok := modMap[strings.ToLower(k)]; ok {
 			if modb&mod == 0 {
 				mods = append(mods, mod)
 				modb = modb | mod
 			}
-			found = true
-		}
-		if ke, ok := keyMap[strings.ToLower(k)]; ok {
+		} else if ke, ok := keyMap[strings.ToLower(k)]; ok {
+			if hasKey {
+				return nil, 0, fmt.Errorf("more than one key in %s", keysStr)
+			}
 			key = ke
-			found = true
-		}
-		if !found {
-			log.Fatalf("invalid key or modifier %s in %s", k, keysStr)
+			hasKey = true
+		} else {
+			return nil, 0, fmt.Errorf("invalid key or modifier %s in %s", k, keysStr)
 		}
 	}
+	if !hasKey {
+		return nil, 0, fmt.Errorf("no key in %s", keysStr)
+	}
 
 	if len(mods) == 1 && mods[0] == hotkey.ModCtrl && (key == hotkey.KeyC || key == hotkey.KeyD) {
-		log.Fatal("cannot use ctrl+c or ctrl+d as a hotkey")
+		return nil, 0, fmt.Errorf("cannot use ctrl+c or ctrl+d as a hotkey")
+	}
+	return mods, key, nil
+}
+
+// bindingSymbol splits a key binding into the key symbol and modifiers in the
+// form i3 reports them in binding events.
+func bindingSymbol(keysStr string) (string, []string) {
+	keys := strings.Split(keysStr, "+")
+	symbol := keys[len(keys)-1]
+	modifiers := []string{}
+	for _, k := range keys[:len(keys)-1] {
+		modifiers = append(modifiers, strings.ToLower(k))
 	}
-	return mods, key
+	return symbol, modifiers
 }
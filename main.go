@@ -8,19 +8,22 @@ import (
 	"os"
 	"os/exec"
 	"path"
+	"time"
 
 	// _ "net/http/pprof"
 
 	"github.com/abibby/salusa/di"
-	"github.com/abibby/yabai3/badparser"
 	"github.com/abibby/yabai3/bar"
+	"github.com/abibby/yabai3/config"
 	"github.com/abibby/yabai3/run"
 	"github.com/abibby/yabai3/server"
 	"github.com/abibby/yabai3/tray"
-	"golang.design/x/hotkey"
+	"github.com/abibby/yabai3/yabai"
 	"golang.design/x/hotkey/mainthread"
 )
 
+const Version = "0.1.0"
+
 var (
 	ErrStop    = errors.New("stop")
 	ErrRestart = errors.New("restart")
@@ -32,14 +35,29 @@ func main() {
 		command = os.Args[1]
 	}
 
+	ctx := di.ContextWithDependencyProvider(
+		context.Background(),
+		di.NewDependencyProvider(),
+	)
+	yabai.RegisterSocket(ctx)
+	run.RegisterLayouts(ctx, run.NewLayouts())
+	run.RegisterOptions(ctx, run.NewOptions())
+	run.RegisterScratchpad(ctx, newScratchpad())
+	run.RegisterMarks(ctx, run.NewMarks())
+	run.RegisterWorkspaceHistory(ctx, run.NewWorkspaceHistory())
+	run.RegisterCreatedWorkspaces(ctx, run.NewCreatedWorkspaces())
+	processes := newProcesses()
+	run.RegisterProcesses(ctx, processes)
+	run.RegisterStartup(ctx, newStartup(processes))
+
 	switch command {
 	case "yabairc":
-		Yabairc()
+		Yabairc(ctx)
+	case "signal":
+		Signal(os.Args[2:])
+	case "validate":
+		Validate(os.Args[2:])
 	default:
-		ctx := di.ContextWithDependencyProvider(
-			context.Background(),
-			di.NewDependencyProvider(),
-		)
 		tray.RegisterVoid(ctx)
 		// tray.RegisterSystray(ctx)
 		tray.Run[*Service](ctx)
@@ -55,7 +73,7 @@ type Service struct {
 }
 
 func (s *Service) Bootstrap() error {
-	log.Print("Starting yabai3 0.1.0")
+	log.Printf("Starting yabai3 %s", Version)
 	s.Tray.SetTitle("yabai3")
 	s.Tray.SetTooltip("yabai3")
 
@@ -100,32 +118,26 @@ func (s *Service) Run() error {
 
 func (s *Service) do(ctx context.Context, cancel context.CancelCauseFunc) error {
 	log.Print("starting yabai3")
-	var modeAST []*badparser.Mode
-	var err error
-	modeAST, err = readConfig()
+	cfg, err := readConfig()
 	if err != nil {
 		log.Fatalf("failed to load config: %v", err)
 	}
 
-	activeMode := "default"
-	modes := map[string]*run.Mode{}
+	y, err := di.Resolve[yabai.Client](ctx)
+	if err != nil {
+		return err
+	}
+	options, err := di.Resolve[*run.Options](ctx)
+	if err != nil {
+		return err
+	}
+	options.Load(cfg)
+	applyConfig(y, cfg)
 
-	i3MsgServer := server.New()
+	i3MsgServer := server.New(Version)
+	i3MsgServer.SetConfig(cfg)
 
-	changeMode := func(mode string) error {
-		i3MsgServer.ModeChanged(mode)
-		newMode, ok := modes[mode]
-		if !ok {
-			return fmt.Errorf("no mode %s", mode)
-		}
-		err := modes[activeMode].Unregister()
-		if err != nil {
-			return err
-		}
-		activeMode = mode
-		log.Printf("Activate mode %s", mode)
-		return newMode.Register()
-	}
+	b := newBindings(i3MsgServer.ModeChanged)
 
 	restart := func() error {
 		err := exec.Command("yabai", "--restart-service").Run()
@@ -137,7 +149,7 @@ func (s *Service) do(ctx context.Context, cancel context.CancelCauseFunc) error
 		return nil
 	}
 
-	err = i3MsgServer.Start(ctx, changeMode, restart)
+	err = i3MsgServer.Start(ctx, b.changeMode, restart)
 	if err != nil {
 		return err
 	}
@@ -148,39 +160,71 @@ func (s *Service) do(ctx context.Context, cancel context.CancelCauseFunc) error
 		}
 	}()
 
-	for _, mode := range modeAST {
-		m := run.NewMode()
-		for _, b := range mode.BindSym {
-			bind := b
-			mods, key := keys(b.Keys)
-			m.AddHotKey(mods, key, func(event hotkey.Event) {
-				for _, c := range bind.Commands {
-					err := run.Command(c, changeMode, restart)
-					if err != nil {
-						log.Print(err)
-					}
-				}
-			})
-		}
-
-		if mode.Name == "default" {
-			go bar.Run(ctx, mode.Bar.StatusCommand, s.menuItems)
-
-			m.SetStartup(mode.Exec)
-			m.SetStartupAlways(mode.ExecAlways)
-		}
-		modes[mode.Name] = m
+	if cfg.Bar != nil {
+		go bar.Run(ctx, cfg.Bar.StatusCommand, s.menuItems)
 	}
 
-	err = modes[activeMode].Register()
+	modes, err := buildModes(ctx, cfg, i3MsgServer, b.changeMode, restart)
+	if err != nil {
+		log.Print(err)
+	}
+	err = b.replace(modes)
 	if err != nil {
 		return fmt.Errorf("failed to register bindings: %w", err)
 	}
 	log.Print("listening for key bindings")
 
-	<-ctx.Done()
+	startup, err := di.Resolve[*run.Startup](ctx)
+	if err != nil {
+		return err
+	}
+	err = startup.Exec(cfg.Exec)
+	if err != nil {
+		log.Printf("failed to save the exec session: %v", err)
+	}
+	startup.ExecAlways(cfg.ExecAlways)
+
+	watchCtx, cancelWatch := context.WithCancel(ctx)
+	changes := config.Watch(watchCtx, cfg.Files(), time.Second)
+
+loop:
+	for {
+		select {
+		case <-ctx.Done():
+			break loop
+		case _, ok := <-changes:
+			// the watcher closes changes when it is stopped, don't reload
+			// while shutting down
+			if !ok || ctx.Err() != nil {
+				break loop
+			}
+			newCfg, err := reload(ctx, y, b, i3MsgServer, b.changeMode, restart)
+			i3MsgServer.ConfigReloaded(err)
+			if err != nil {
+				log.Printf("failed to reload config: %v", err)
+				s.Tray.SetTitle("yabai3 (config error)")
+				s.Tray.SetTooltip(err.Error())
+				continue
+			}
+			log.Print("reloaded config")
+			s.Tray.SetTitle("yabai3")
+			s.Tray.SetTooltip("yabai3")
 
-	err = modes[activeMode].Unregister()
+			cancelWatch()
+			watchCtx, cancelWatch = context.WithCancel(ctx)
+			changes = config.Watch(watchCtx, newCfg.Files(), time.Second)
+		}
+	}
+	cancelWatch()
+
+	switch context.Cause(ctx) {
+	case ErrRestart:
+		i3MsgServer.Shutdown("restart")
+	case ErrStop:
+		i3MsgServer.Shutdown("exit")
+	}
+
+	err = b.unregister()
 	if err != nil {
 		return fmt.Errorf("failed to unregister bindings: %w", err)
 	}
@@ -188,7 +232,44 @@ func (s *Service) do(ctx context.Context, cancel context.CancelCauseFunc) error
 	return nil
 }
 
-func readConfig() ([]*badparser.Mode, error) {
+// newScratchpad loads the scratchpad saved by the last run, falling back to
+// one that isn't saved if the state file can't be used.
+func newScratchpad() *run.Scratchpad {
+	p, err := run.StatePath("scratchpad.json")
+	if err == nil {
+		s, err := run.NewScratchpad(p)
+		if err == nil {
+			return s
+		}
+	}
+	log.Printf("failed to load scratchpad: %v", err)
+	s, _ := run.NewScratchpad("")
+	return s
+}
+
+// newProcesses keeps the output of exec'd commands in the state directory,
+// it is thrown away if there is no state directory.
+func newProcesses() *run.Processes {
+	p, err := run.StatePath("exec")
+	if err != nil {
+		log.Printf("failed to find the exec log directory: %v", err)
+		return run.NewProcesses("")
+	}
+	return run.NewProcesses(p)
+}
+
+// newStartup remembers the login session exec commands ran in, without a
+// state directory they run every time yabai3 starts.
+func newStartup(processes *run.Processes) *run.Startup {
+	p, err := run.StatePath("startup.json")
+	if err != nil {
+		log.Printf("failed to find the startup state file: %v", err)
+		return run.NewStartup("", processes)
+	}
+	return run.NewStartup(p, processes)
+}
+
+func readConfig() (*config.Config, error) {
 	home, err := os.UserHomeDir()
 	if err != nil {
 		log.Fatal(err)
@@ -204,13 +285,13 @@ func readConfig() ([]*badparser.Mode, error) {
 	}
 
 	for _, path := range configPaths {
-		modeAST, err := badparser.ParseFile(path)
+		cfg, err := config.Load(path)
 		if errors.Is(err, os.ErrNotExist) {
 			continue
 		} else if err != nil {
 			return nil, err
 		}
-		return modeAST, nil
+		return cfg, nil
 	}
 	return nil, fmt.Errorf("no config file found")
 }
@@ -20,6 +20,17 @@ func NewError(r *Reader, err error) *Error {
 	}
 }
 
+// NewNodeError returns an error located at the start of n.
+func NewNodeError(r *Reader, n Node, err error) *Error {
+	line, column := r.Position(n.Pos())
+	return &Error{
+		file:    r.File(),
+		line:    line,
+		column:  column,
+		wrapped: err,
+	}
+}
+
 func (e *Error) Error() string {
 	return fmt.Sprintf("%s:%d:%d: %s", e.file, e.line, e.column, e.wrapped.Error())
 }
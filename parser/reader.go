@@ -1,6 +1,10 @@
 package parser
 
-import "os"
+import (
+	"os"
+	"slices"
+	"strings"
+)
 
 const EOF = byte(0xff)
 
@@ -11,6 +15,7 @@ type Reader struct {
 	whitespace []byte
 	line       int
 	column     int
+	variables  map[string]string
 }
 
 type ReaderTx struct {
@@ -60,6 +65,7 @@ func NewReader(file string, b []byte) *Reader {
 		line:       1,
 		column:     1,
 		whitespace: []byte(" \n\t"),
+		variables:  map[string]string{},
 	}
 }
 
@@ -105,7 +111,10 @@ func (r *Reader) ReadWord() []byte {
 }
 
 func (r *Reader) PeakN(length int) []byte {
-	return r.source[r.cursor : r.cursor+length]
+	if r.cursor >= len(r.source) {
+		return nil
+	}
+	return r.source[r.cursor:min(r.cursor+length, len(r.source))]
 }
 func (r *Reader) ReadN(length int) []byte {
 	b := r.PeakN(length)
@@ -154,3 +163,64 @@ func (r *Reader) Line() int {
 func (r *Reader) Column() int {
 	return r.column
 }
+
+// Position returns the line and column of an offset in the source.
+func (r *Reader) Position(pos int) (int, int) {
+	line := 1
+	column := 1
+	for _, c := range r.source[:min(pos, len(r.source))] {
+		if c == '\n' {
+			line++
+			column = 1
+		} else {
+			column++
+		}
+	}
+	return line, column
+}
+
+// InheritVariables shares the variables of parent with r, variables set by
+// either reader are visible to both.
+func (r *Reader) InheritVariables(parent *Reader) {
+	r.variables = parent.variables
+}
+
+func (r *Reader) SetVariable(name, value string) {
+	r.variables[name] = value
+}
+
+// Expand replaces variables in s with their values. Longer names are matched
+// first so $mod never replaces the start of $mod2.
+func (r *Reader) Expand(s string) string {
+	if len(r.variables) == 0 || !strings.Contains(s, "$") {
+		return s
+	}
+	names := make([]string, 0, len(r.variables))
+	for name := range r.variables {
+		names = append(names, name)
+	}
+	slices.SortFunc(names, func(a, b string) int {
+		return len(b) - len(a)
+	})
+
+	result := strings.Builder{}
+	for i := 0; i < len(s); {
+		if s[i] == '$' {
+			name := ""
+			for _, n := range names {
+				if strings.HasPrefix(s[i:], n) {
+					name = n
+					break
+				}
+			}
+			if name != "" {
+				result.WriteString(r.variables[name])
+				i += len(name)
+				continue
+			}
+		}
+		result.WriteByte(s[i])
+		i++
+	}
+	return result.String()
+}
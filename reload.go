@@ -0,0 +1,198 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"strings"
+	"sync"
+
+	"github.com/abibby/salusa/di"
+	"github.com/abibby/yabai3/config"
+	"github.com/abibby/yabai3/run"
+	"github.com/abibby/yabai3/server"
+	"github.com/abibby/yabai3/yabai"
+	"golang.design/x/hotkey"
+)
+
+// bindings holds the hotkeys of every mode and tracks which one is
+// registered.
+type bindings struct {
+	mtx         *sync.Mutex
+	modes       map[string]*run.Mode
+	activeMode  string
+	modeChanged func(mode string)
+}
+
+func newBindings(modeChanged func(mode string)) *bindings {
+	return &bindings{
+		mtx:         &sync.Mutex{},
+		modes:       map[string]*run.Mode{},
+		activeMode:  "default",
+		modeChanged: modeChanged,
+	}
+}
+
+func (b *bindings) changeMode(mode string) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	b.modeChanged(mode)
+	newMode, ok := b.modes[mode]
+	if !ok {
+		return fmt.Errorf("no mode %s", mode)
+	}
+	err := b.modes[b.activeMode].Unregister()
+	if err != nil {
+		return err
+	}
+	b.activeMode = mode
+	log.Printf("Activate mode %s", mode)
+	return newMode.Register()
+}
+
+// replace swaps in the hotkeys from a new config. If any of them fail to
+// register the old hotkeys are registered again.
+func (b *bindings) replace(modes map[string]*run.Mode) error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+
+	old, ok := b.modes[b.activeMode]
+	if ok {
+		err := old.Unregister()
+		if err != nil {
+			return err
+		}
+	}
+
+	active := b.activeMode
+	if _, ok := modes[active]; !ok {
+		active = "default"
+	}
+	err := modes[active].Register()
+	if err != nil {
+		if old != nil {
+			err = errors.Join(err, old.Register())
+		}
+		return err
+	}
+
+	b.modes = modes
+	if active != b.activeMode {
+		b.activeMode = active
+		b.modeChanged(active)
+	}
+	return nil
+}
+
+func (b *bindings) unregister() error {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	m, ok := b.modes[b.activeMode]
+	if !ok {
+		return nil
+	}
+	return m.Unregister()
+}
+
+// buildModes creates the hotkeys for every mode in cfg. Bindings with invalid
+// keys are left out and returned as errors.
+func buildModes(ctx context.Context, cfg *config.Config, i3MsgServer *server.I3MsgServer, changeMode func(string) error, restart func() error) (map[string]*run.Mode, error) {
+	errs := []error{}
+	modes := map[string]*run.Mode{}
+	for _, mode := range cfg.Modes {
+		m := run.NewMode()
+		for _, b := range mode.BindSyms {
+			mods, key, err := keys(b.Keys.Value)
+			if err != nil {
+				errs = append(errs, b.NodeError(b.Keys, err))
+				continue
+			}
+			commands := b.Commands.List()
+			symbol, modifiers := bindingSymbol(b.Keys.Value)
+			commandStrs := make([]string, len(commands))
+			for i, c := range commands {
+				commandStrs[i] = strings.Join(c.Args(), " ")
+			}
+			command := strings.Join(commandStrs, "; ")
+			m.AddHotKey(mods, key, func(event hotkey.Event) {
+				i3MsgServer.BindingTriggered(command, symbol, modifiers)
+				for _, err := range run.Commands(ctx, b.Commands, changeMode, restart) {
+					if err != nil {
+						log.Print(err)
+					}
+				}
+				i3MsgServer.Refresh()
+			})
+		}
+		modes[mode.Name] = m
+	}
+	return modes, errors.Join(errs...)
+}
+
+// applyConfig labels spaces, sets gaps and adds the static assign and
+// for_window rules from the config. It runs whenever a config is loaded so
+// warnings about the config are logged here too.
+func applyConfig(y yabai.Client, cfg *config.Config) {
+	for _, warning := range configWarnings(cfg) {
+		log.Printf("warning: %v", warning)
+	}
+	spaceCache := map[int]struct{}{}
+	for _, w := range cfg.Workspaces {
+		err := run.LabelSpace(y, spaceCache, w.Outputs, w.Name)
+		if err != nil {
+			log.Print(err)
+		}
+	}
+	err := run.SetGaps(y, cfg.Gaps)
+	if err != nil {
+		log.Print(err)
+	}
+
+	rules := [][]string{}
+	for _, a := range cfg.Assigns {
+		if rule, ok := run.AssignRule(a.Assign); ok {
+			rules = append(rules, rule)
+		}
+	}
+	for _, w := range cfg.ForWindows {
+		if rule, ok := run.StaticRule(w.Conditions, w.Commands); ok {
+			rules = append(rules, rule)
+		}
+	}
+	err = run.SetRules(y, rules)
+	if err != nil {
+		log.Print(err)
+	}
+}
+
+// reload loads the config again and replaces the running one. The running
+// config is left in place if anything fails.
+func reload(ctx context.Context, y yabai.Client, b *bindings, i3MsgServer *server.I3MsgServer, changeMode func(string) error, restart func() error) (*config.Config, error) {
+	options, err := di.Resolve[*run.Options](ctx)
+	if err != nil {
+		return nil, err
+	}
+	startup, err := di.Resolve[*run.Startup](ctx)
+	if err != nil {
+		return nil, err
+	}
+	cfg, err := readConfig()
+	if err != nil {
+		return nil, err
+	}
+	modes, err := buildModes(ctx, cfg, i3MsgServer, changeMode, restart)
+	if err != nil {
+		return nil, err
+	}
+	err = b.replace(modes)
+	if err != nil {
+		return nil, err
+	}
+	i3MsgServer.SetConfig(cfg)
+	options.Load(cfg)
+	applyConfig(y, cfg)
+	startup.ExecAlways(cfg.ExecAlways)
+	return cfg, nil
+}
@@ -0,0 +1,72 @@
+package run
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/abibby/yabai3/i3parser"
+	"github.com/abibby/yabai3/yabai"
+)
+
+// AssignWorkspace returns the name of the workspace an assign moves windows
+// to.
+func AssignWorkspace(a *i3parser.Assign) string {
+	return a.Target.Value
+}
+
+// AssignRule returns the properties of a yabai rule that moves windows to the
+// assign's workspace. ok is false for assigns to outputs, which depend on how
+// the displays are arranged when the window is created, for assigns by
+// number, which depend on the names of the workspaces at that point, and for
+// criteria a yabai rule can't express.
+func AssignRule(a *i3parser.Assign) (properties []string, ok bool) {
+	if a.Output != nil || a.Number != nil {
+		return nil, false
+	}
+	matches, ok := ruleMatches(a.Conditions)
+	if !ok {
+		return nil, false
+	}
+	return append(matches, "space="+AssignWorkspace(a)), true
+}
+
+// Assign moves w to the assign's workspace or output. `assign [...] number 3`
+// finds the workspace the way `workspace number 3` does.
+func Assign(y yabai.Client, w *yabai.Window, a *i3parser.Assign) error {
+	id := strconv.Itoa(w.ID)
+	if a.Output == nil {
+		spaces, err := y.QuerySpaces()
+		if err != nil {
+			return err
+		}
+		var target *yabai.Space
+		if a.Number != nil {
+			target, _, err = findWorkspace(spaces, NewWorkspaceHistory(), []string{"number", a.Target.Value})
+			if err != nil {
+				return err
+			}
+		} else {
+			target = namedWorkspace(spaces, a.Target.Value)
+		}
+		if target == nil {
+			return fmt.Errorf("no workspace %s", a.Target.Value)
+		}
+		if target.Index == w.Space {
+			return nil
+		}
+		return y.Yabai("window", id, "--space", strconv.Itoa(target.Index))
+	}
+	d, err := getOutput(y, a.Target.Value)
+	if err != nil {
+		return fmt.Errorf("output %s: %w", a.Target.Value, err)
+	}
+	if d.Index == w.Display {
+		return nil
+	}
+	return y.Yabai("window", id, "--display", strconv.Itoa(d.Index))
+}
+
+// getOutput finds a display by any of the names getDisplayFrom accepts.
+func getOutput(y yabai.Client, name string) (*yabai.Display, error) {
+	return getDisplayFrom(y, []string{name})
+}
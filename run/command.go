@@ -1,16 +1,15 @@
 package run
 
 import (
+	"context"
 	"errors"
 	"fmt"
 	"math"
-	"os/exec"
-	"strconv"
 	"strings"
-	"syscall"
 
+	"github.com/abibby/salusa/di"
+	"github.com/abibby/yabai3/i3parser"
 	"github.com/abibby/yabai3/yabai"
-	"github.com/mattn/go-shellwords"
 	"golang.org/x/exp/slices"
 )
 
@@ -23,109 +22,240 @@ var directionMap = map[string]string{
 	"right": "east",
 }
 
-func Command(command []string, changeMode func(string) error, restart func() error) error {
-	runners := map[string]func(c []string) error{
-		"exec":       runExec,
+type runner func(y yabai.Client, c []string) error
+
+// session is the state commands share between calls.
+type session struct {
+	layouts    *Layouts
+	options    *Options
+	scratchpad *Scratchpad
+	marks      *Marks
+	history    *WorkspaceHistory
+	created    *CreatedWorkspaces
+	processes  *Processes
+}
+
+func runners(s *session, changeMode func(string) error, restart func() error) map[string]runner {
+	return map[string]runner{
+		"exec":       runExec(s.processes),
 		"focus":      runFocus,
-		"move":       runMove,
+		"move":       runMove(s),
 		"resize":     runResize,
-		"workspace":  runWorkspace,
+		"workspace":  runWorkspace(s),
 		"mode":       runMode(changeMode),
 		"fullscreen": runFullscreen,
 		"restart":    runRestart(restart),
-		"kill":       runKill,
-	}
-	runner, ok := runners[command[0]]
-	if !ok {
-		return fmt.Errorf("missing implementation for command %s", strings.Join(command, " "))
+		"kill":       runKill(s.options),
+		"floating":   runFloating(s.options),
+		"sticky":     runSticky,
+		"border":     runBorder,
+		"layout":     runLayout(s.layouts),
+		"split":      runSplit,
+		"scratchpad": runScratchpad(s),
+		"mark":       runMark(s.marks),
+		"unmark":     runUnmark(s.marks),
+		"swap":       runSwap(s.marks),
 	}
-	err := runner(command)
+}
+
+// IsCommand reports whether Command has an implementation for commands
+// starting with name.
+func IsCommand(name string) bool {
+	_, ok := runners(&session{}, nil, nil)[name]
+	return ok
+}
+
+// globalCommands don't act on a window, they run once for a chain no matter
+// how many windows its criteria match.
+var globalCommands = []string{"exec", "workspace", "mode", "restart"}
+
+func Command(ctx context.Context, command []string, changeMode func(string) error, restart func() error) error {
+	y, _, r, err := resolveRunners(ctx, changeMode, restart)
 	if err != nil {
-		return fmt.Errorf("%s: %w", strings.Join(command, " "), err)
+		return err
 	}
-	return nil
+	return runCommand(y, r, command)
 }
 
-func runExec(c []string) error {
-	cmd := c[1]
-	args, err := shellwords.Parse(cmd)
-	if err == nil {
-		b, err := exec.Command("open", append([]string{"-a", args[0], "-n", "--args"}, args[1:]...)...).CombinedOutput()
-		if err == nil {
-			return nil
-		}
-		if string(b) != fmt.Sprintf("Unable to find application named '%s'\n", args[0]) {
-			return fmt.Errorf("exec failed with message: \"%s\": %w", b, err)
-		}
+func resolveRunners(ctx context.Context, changeMode func(string) error, restart func() error) (yabai.Client, *session, map[string]runner, error) {
+	y, err := di.Resolve[yabai.Client](ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	layouts, err := di.Resolve[*Layouts](ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	options, err := di.Resolve[*Options](ctx)
+	if err != nil {
+		return nil, nil, nil, err
 	}
-	b, err := exec.Command("sh", "-c", cmd).CombinedOutput()
+	scratchpad, err := di.Resolve[*Scratchpad](ctx)
 	if err != nil {
-		return fmt.Errorf("exec failed with message: \"%s\": %w", b, err)
+		return nil, nil, nil, err
 	}
+	marks, err := di.Resolve[*Marks](ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	history, err := di.Resolve[*WorkspaceHistory](ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	created, err := di.Resolve[*CreatedWorkspaces](ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	processes, err := di.Resolve[*Processes](ctx)
+	if err != nil {
+		return nil, nil, nil, err
+	}
+	s := &session{
+		layouts:    layouts,
+		options:    options,
+		scratchpad: scratchpad,
+		marks:      marks,
+		history:    history,
+		created:    created,
+		processes:  processes,
+	}
+	return y, s, runners(s, changeMode, restart), nil
+}
 
-	return nil
+// Commands runs every command chain and returns an error for each command in
+// commands.List(), nil if it succeeded. Chains with criteria run each of their
+// commands against every matching window.
+func Commands(ctx context.Context, commands *i3parser.Commands, changeMode func(string) error, restart func() error) []error {
+	return runChains(ctx, nil, commands, changeMode, restart)
+}
+
+// WindowCommands runs commands like Commands but chains without criteria act
+// on w instead of the focused window.
+func WindowCommands(ctx context.Context, w *yabai.Window, commands *i3parser.Commands, changeMode func(string) error, restart func() error) []error {
+	return runChains(ctx, w, commands, changeMode, restart)
 }
 
-func runResize(c []string) error {
-	parts := c[1:]
-	horizontal := 0
-	vertial := 0
-	direction := "right"
-	if parts[0] == "shrink" && parts[1] == "width" {
-		direction = "right"
-		horizontal = -1
-	} else if parts[0] == "grow" && parts[1] == "width" {
-		direction = "right"
-		horizontal = 1
-	} else if parts[0] == "shrink" && parts[1] == "height" {
-		direction = "bottom"
-		vertial = -1
-	} else if parts[0] == "grow" && parts[1] == "height" {
-		direction = "bottom"
-		vertial = 1
-	}
-
-	scale := 10
-
-	amount, err := strconv.Atoi(parts[2])
+func runChains(ctx context.Context, w *yabai.Window, commands *i3parser.Commands, changeMode func(string) error, restart func() error) []error {
+	errs := make([]error, 0, len(commands.List()))
+	y, s, r, err := resolveRunners(ctx, changeMode, restart)
 	if err != nil {
-		return err
+		for range commands.List() {
+			errs = append(errs, err)
+		}
+		return errs
 	}
 
-	err = yabai.Yabai("window", "--resize", fmt.Sprintf("%s:%d:%d", direction, amount*horizontal*scale, amount*vertial*scale))
-	if err == nil {
+	for _, chain := range commands.Chains {
+		var windows []*yabai.Window
+		var err error
+		if chain.Conditions != nil {
+			windows, err = MatchWindows(y, s.marks, chain.Conditions)
+		} else if w != nil {
+			windows = []*yabai.Window{w}
+		}
+		for _, c := range chain.Commands {
+			if err != nil {
+				errs = append(errs, err)
+			} else if windows == nil {
+				errs = append(errs, runCommand(y, r, c.Args()))
+			} else {
+				errs = append(errs, runChainCommand(y, r, windows, c.Args()))
+			}
+		}
+	}
+	return errs
+}
+
+func runChainCommand(y yabai.Client, r map[string]runner, windows []*yabai.Window, command []string) error {
+	if len(windows) == 0 {
 		return nil
 	}
+	if slices.Contains(globalCommands, command[0]) {
+		return runCommand(y, r, command)
+	}
+	errs := []error{}
+	for _, w := range windows {
+		err := runCommand(&windowClient{Client: y, window: w}, r, command)
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
 
-	amount = -amount
-	if direction == "bottom" {
-		direction = "top"
-	} else if direction == "right" {
-		direction = "left"
+func runCommand(y yabai.Client, r map[string]runner, command []string) error {
+	runner, ok := r[command[0]]
+	if !ok {
+		return fmt.Errorf("missing implementation for command %s", strings.Join(command, " "))
 	}
+	err := runner(y, command)
+	if err != nil {
+		return fmt.Errorf("%s: %w", strings.Join(command, " "), err)
+	}
+	return nil
+}
 
-	return yabai.Yabai("window", "--resize", fmt.Sprintf("%s:%d:%d", direction, amount*horizontal, amount*vertial))
+// moveTarget strips the optional window or container and to words from a
+// move command, `move container to workspace 2` and `move to workspace 2`
+// both return [workspace 2].
+func moveTarget(c []string) []string {
+	target := c[1:]
+	if len(target) > 0 && (target[0] == "window" || target[0] == "container") {
+		target = target[1:]
+	}
+	if len(target) > 0 && target[0] == "to" {
+		target = target[1:]
+	}
+	return target
+}
 
+func runMove(s *session) runner {
+	return func(y yabai.Client, c []string) error {
+		target := moveTarget(c)
+		if len(target) == 1 && target[0] == "scratchpad" {
+			return moveScratchpad(y, s.scratchpad)
+		}
+		if len(target) > 0 && target[0] == "output" {
+			return moveToOutput(y, target[1:])
+		}
+		if len(target) > 2 && target[0] == "workspace" && target[1] == "to" && target[2] == "output" {
+			return moveWorkspaceToOutput(y, target[3:])
+		}
+		return move(y, s, target)
+	}
 }
-func runMove(c []string) error {
-	direction, ok := directionMap[c[1]]
+
+func move(y yabai.Client, s *session, target []string) error {
+	if len(target) == 0 {
+		return ErrUnknownCommand
+	}
+	direction, ok := directionMap[target[0]]
 	if !ok {
-		if !slices.Equal([]string{"move", "container", "to", "workspace"}, c[:4]) {
-			return ErrUnknownCommand
+		switch target[0] {
+		case "position", "absolute":
+			return movePosition(y, target)
+		case "workspace":
+			if len(target) >= 2 {
+				return moveToWorkspace(y, s, target[1:])
+			}
 		}
-		return yabai.Yabai("window", "--space", c[4])
+		return ErrUnknownCommand
 	}
 
-	err := yabai.Yabai("window", "--swap", direction)
+	window, err := y.QueryActiveWindow()
+	if err != nil {
+		return err
+	}
+	if window.IsFloating {
+		return nudgeFloating(y, window, direction, target[1:])
+	}
+
+	err = y.Yabai("window", "--swap", direction)
 	if err == nil {
 		return nil
 	}
 
-	window, err := yabai.QueryActiveWindow()
-	if err != nil {
-		return err
-	}
-	nextSpace, err := getSpaceInDirection(direction)
+	nextSpace, err := getSpaceInDirection(y, direction)
 	if err != nil {
 		return err
 	}
@@ -135,60 +265,64 @@ func runMove(c []string) error {
 		label = fmt.Sprint(nextSpace.Index)
 	}
 
-	err = yabai.Yabai("window", "--space", label)
+	err = y.Yabai("window", "--space", label)
 	if err != nil {
 		return err
 	}
 
-	return yabai.Yabai("window", "--focus", fmt.Sprint(window.ID))
+	return y.Yabai("window", "--focus", fmt.Sprint(window.ID))
 }
 
-func runFocus(c []string) error {
+func runFocus(y yabai.Client, c []string) error {
+	if len(c) == 1 {
+		return y.Yabai("window", "--focus")
+	}
+	switch c[1] {
+	case "floating", "tiling", "mode_toggle":
+		return focusMode(y, c[1])
+	case "output":
+		return runFocusOutput(y, c[2:])
+	}
 	direction, ok := directionMap[c[1]]
 	if !ok {
 		return ErrUnknownCommand
 	}
-	err := yabai.Yabai("window", "--focus", direction)
+	err := y.Yabai("window", "--focus", direction)
 	if err == nil {
 		return nil
 	}
 
-	display, err := getDisplayInDirection(direction)
+	display, err := getDisplayInDirection(y, direction)
 	if err != nil {
 		return err
 	}
 
-	return yabai.Yabai("display", "--focus", fmt.Sprint(display.Index))
-}
-
-func runWorkspace(c []string) error {
-	return yabai.Yabai("space", "--focus", c[1])
+	return y.Yabai("display", "--focus", fmt.Sprint(display.Index))
 }
 
-func runMode(changeMode func(string) error) func(c []string) error {
-	return func(c []string) error {
+func runMode(changeMode func(string) error) runner {
+	return func(y yabai.Client, c []string) error {
 		return changeMode(c[1])
 	}
 }
 
-func runFullscreen(c []string) error {
-	if c[1] == "toggle" {
-		return yabai.Yabai("window", "--toggle", "zoom-fullscreen")
+func runFullscreen(y yabai.Client, c []string) error {
+	if len(c) == 1 || c[1] == "toggle" {
+		return y.Yabai("window", "--toggle", "zoom-fullscreen")
 	}
 	return ErrUnknownCommand
 }
 
-func runRestart(restart func() error) func(c []string) error {
-	return func(c []string) error {
-		return restart()
-	}
+// runBorder accepts border commands so configs written for i3 keep working,
+// yabai doesn't draw window borders so there is nothing to change.
+func runBorder(y yabai.Client, c []string) error {
+	return nil
 }
-func runKill(c []string) error {
-	w, err := yabai.QueryActiveWindow()
-	if err != nil {
-		return err
+
+func runRestart(restart func() error) runner {
+	return func(y yabai.Client, c []string) error {
+		return restart()
 	}
-	return syscall.Kill(w.PID, syscall.SIGTERM)
 }
 func findAngleBetween(d1, d2 *yabai.Display) float64 {
 	x1 := d1.Frame.X + d1.Frame.Width/2
@@ -201,26 +335,18 @@ func findAngleBetween(d1, d2 *yabai.Display) float64 {
 	}
 	return calcAngle * (180 / math.Pi)
 }
-func getDisplayInDirection(direction string) (*yabai.Display, error) {
-	spaces, err := yabai.QuerySpaces()
+func getDisplayInDirection(y yabai.Client, direction string) (*yabai.Display, error) {
+	activeSpace, err := y.QueryActiveSpace()
 	if err != nil {
 		return nil, err
 	}
-	displays, err := yabai.QueryDisplays()
+	displays, err := y.QueryDisplays()
 	if err != nil {
 		return nil, err
 	}
-	var activeSpace *yabai.Space
 	var activeDisplay *yabai.Display
 	var nextDisplay *yabai.Display
 
-	for _, s := range spaces {
-		if s.HasFocus {
-			activeSpace = s
-			break
-		}
-	}
-
 	for _, d := range displays {
 		if d.Index == activeSpace.DisplayIndex {
 			activeDisplay = d
@@ -261,13 +387,13 @@ func getDisplayInDirection(direction string) (*yabai.Display, error) {
 	}
 	return nextDisplay, nil
 }
-func getSpaceInDirection(direction string) (*yabai.Space, error) {
+func getSpaceInDirection(y yabai.Client, direction string) (*yabai.Space, error) {
 	var nextSpace *yabai.Space
-	spaces, err := yabai.QuerySpaces()
+	spaces, err := y.QuerySpaces()
 	if err != nil {
 		return nil, err
 	}
-	nextDisplay, err := getDisplayInDirection(direction)
+	nextDisplay, err := getDisplayInDirection(y, direction)
 	if err != nil {
 		return nil, err
 	}
@@ -0,0 +1,323 @@
+package run
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abibby/salusa/di"
+	"github.com/abibby/yabai3/config"
+	"github.com/abibby/yabai3/i3parser"
+	"github.com/abibby/yabai3/yabai"
+	"github.com/stretchr/testify/assert"
+)
+
+func newTestContext(f *yabai.Fake) context.Context {
+	ctx := di.ContextWithDependencyProvider(
+		context.Background(),
+		di.NewDependencyProvider(),
+	)
+	yabai.RegisterFake(ctx, f)
+	RegisterLayouts(ctx, NewLayouts())
+	RegisterOptions(ctx, NewOptions())
+	scratchpad, _ := NewScratchpad("")
+	RegisterScratchpad(ctx, scratchpad)
+	RegisterMarks(ctx, NewMarks())
+	RegisterWorkspaceHistory(ctx, NewWorkspaceHistory())
+	RegisterCreatedWorkspaces(ctx, NewCreatedWorkspaces())
+	RegisterProcesses(ctx, NewProcesses(""))
+	return ctx
+}
+
+func noChangeMode(mode string) error { return nil }
+func noRestart() error               { return nil }
+
+// newTwoDisplayFake builds a left and right display, each with one space. The
+// left space has the windows a and b, the right space has c.
+func newTwoDisplayFake() *yabai.Fake {
+	f := yabai.NewFake()
+	f.AddDisplay(yabai.Frame{X: 0, Y: 0, Width: 1000, Height: 800})
+	f.AddDisplay(yabai.Frame{X: 1000, Y: 0, Width: 1000, Height: 800})
+	f.AddWindow(1, "a", "a")
+	f.AddWindow(1, "b", "b")
+	f.AddWindow(2, "c", "c")
+	return f
+}
+
+func activeApp(t *testing.T, f *yabai.Fake) string {
+	w, err := f.QueryActiveWindow()
+	if !assert.NoError(t, err) {
+		return ""
+	}
+	return w.App
+}
+
+func windowSpace(t *testing.T, f *yabai.Fake, app string) int {
+	windows, err := f.QueryWindows()
+	assert.NoError(t, err)
+	for _, w := range windows {
+		if w.App == app {
+			return w.Space
+		}
+	}
+	t.Fatalf("no window %s", app)
+	return 0
+}
+
+func TestCommand(t *testing.T) {
+	testCases := []struct {
+		name     string
+		commands [][]string
+		check    func(t *testing.T, f *yabai.Fake)
+	}{
+		{
+			name:     "focus right within space",
+			commands: [][]string{{"focus", "right"}},
+			check: func(t *testing.T, f *yabai.Fake) {
+				assert.Equal(t, "b", activeApp(t, f))
+			},
+		},
+		{
+			name:     "focus right across displays",
+			commands: [][]string{{"focus", "right"}, {"focus", "right"}},
+			check: func(t *testing.T, f *yabai.Fake) {
+				assert.Equal(t, "c", activeApp(t, f))
+			},
+		},
+		{
+			name:     "move right swaps",
+			commands: [][]string{{"move", "right"}},
+			check: func(t *testing.T, f *yabai.Fake) {
+				s, err := f.QueryActiveSpace()
+				assert.NoError(t, err)
+				assert.Equal(t, []int{6, 5}, s.WindowIDs)
+			},
+		},
+		{
+			name:     "move right to next display",
+			commands: [][]string{{"focus", "right"}, {"move", "right"}},
+			check: func(t *testing.T, f *yabai.Fake) {
+				assert.Equal(t, 2, windowSpace(t, f, "b"))
+				assert.Equal(t, "b", activeApp(t, f))
+			},
+		},
+		{
+			name:     "move container to workspace",
+			commands: [][]string{{"move", "container", "to", "workspace", "2"}},
+			check: func(t *testing.T, f *yabai.Fake) {
+				assert.Equal(t, 2, windowSpace(t, f, "a"))
+			},
+		},
+		{
+			name:     "workspace",
+			commands: [][]string{{"workspace", "2"}},
+			check: func(t *testing.T, f *yabai.Fake) {
+				assert.Equal(t, "c", activeApp(t, f))
+			},
+		},
+		{
+			name:     "resize grow width",
+			commands: [][]string{{"resize", "grow", "width", "10"}},
+			check: func(t *testing.T, f *yabai.Fake) {
+				w, err := f.QueryActiveWindow()
+				assert.NoError(t, err)
+				assert.Equal(t, float32(510), w.Frame.Width)
+			},
+		},
+		{
+			name:     "fullscreen toggle",
+			commands: [][]string{{"fullscreen", "toggle"}},
+			check: func(t *testing.T, f *yabai.Fake) {
+				w, err := f.QueryActiveWindow()
+				assert.NoError(t, err)
+				assert.True(t, w.HasFullscreenZoom)
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newTwoDisplayFake()
+			ctx := newTestContext(f)
+			for _, c := range tc.commands {
+				err := Command(ctx, c, noChangeMode, noRestart)
+				assert.NoError(t, err)
+			}
+			tc.check(t, f)
+		})
+	}
+}
+
+func TestCommand_unknown(t *testing.T) {
+	ctx := newTestContext(newTwoDisplayFake())
+	err := Command(ctx, []string{"nope"}, noChangeMode, noRestart)
+	assert.Error(t, err)
+}
+
+func TestSetGaps(t *testing.T) {
+	f := newTwoDisplayFake()
+	err := SetGaps(f, &config.Gaps{Inner: 5, Top: 10, Right: 10, Bottom: 20, Left: 10})
+	assert.NoError(t, err)
+	assert.Equal(t, "5", f.Config("window_gap"))
+	assert.Equal(t, "10", f.Config("left_padding"))
+	assert.Equal(t, "20", f.Config("bottom_padding"))
+}
+
+func TestLabelSpace(t *testing.T) {
+	f := newTwoDisplayFake()
+	f.AddSpace(2)
+	spaceCache := map[int]struct{}{}
+	assert.NoError(t, LabelSpace(f, spaceCache, []string{"right"}, "web"))
+	assert.NoError(t, LabelSpace(f, spaceCache, []string{"right"}, "chat"))
+
+	spaces, err := f.QuerySpaces()
+	assert.NoError(t, err)
+	assert.Equal(t, "", spaces[0].Label)
+	assert.Equal(t, "web", spaces[1].Label)
+	assert.Equal(t, "chat", spaces[2].Label)
+}
+
+func TestCommands_criteria(t *testing.T) {
+	testCases := []struct {
+		name    string
+		command string
+		check   func(t *testing.T, f *yabai.Fake)
+	}{
+		{
+			name:    "focus by class",
+			command: `[class="^c$"] focus`,
+			check: func(t *testing.T, f *yabai.Fake) {
+				assert.Equal(t, "c", activeApp(t, f))
+			},
+		},
+		{
+			name:    "chain runs against each match",
+			command: `[workspace=1] move container to workspace 2, fullscreen`,
+			check: func(t *testing.T, f *yabai.Fake) {
+				assert.Equal(t, 2, windowSpace(t, f, "a"))
+				assert.Equal(t, 2, windowSpace(t, f, "b"))
+				windows, err := f.QueryWindows()
+				assert.NoError(t, err)
+				for _, w := range windows {
+					assert.Equal(t, w.App != "c", w.HasFullscreenZoom, w.App)
+				}
+			},
+		},
+		{
+			name:    "con_id",
+			command: `[con_id=6] fullscreen toggle`,
+			check: func(t *testing.T, f *yabai.Fake) {
+				windows, err := f.QueryWindows()
+				assert.NoError(t, err)
+				for _, w := range windows {
+					assert.Equal(t, w.App == "b", w.HasFullscreenZoom, w.App)
+				}
+			},
+		},
+		{
+			name:    "focused",
+			command: `[workspace=__focused__ tiling] move container to workspace 2`,
+			check: func(t *testing.T, f *yabai.Fake) {
+				assert.Equal(t, 2, windowSpace(t, f, "a"))
+				assert.Equal(t, 2, windowSpace(t, f, "b"))
+			},
+		},
+		{
+			name:    "chain sees earlier commands",
+			command: `[class="^b$"] floating enable, move position center`,
+			check: func(t *testing.T, f *yabai.Fake) {
+				windows, err := f.QueryWindows()
+				assert.NoError(t, err)
+				for _, w := range windows {
+					if w.App == "b" {
+						assert.True(t, w.IsFloating)
+						assert.Equal(t, float32(500), w.Frame.X+w.Frame.Width/2)
+					}
+				}
+			},
+		},
+		{
+			name:    "floating enable twice",
+			command: `[class="^c$"] floating enable, floating enable`,
+			check: func(t *testing.T, f *yabai.Fake) {
+				windows, err := f.QueryWindows()
+				assert.NoError(t, err)
+				for _, w := range windows {
+					assert.Equal(t, w.App == "c", w.IsFloating, w.App)
+				}
+			},
+		},
+		{
+			name:    "layout of the matched window's workspace",
+			command: `[class="^c$"] layout stacking`,
+			check: func(t *testing.T, f *yabai.Fake) {
+				spaces, err := f.QuerySpaces()
+				assert.NoError(t, err)
+				assert.Equal(t, "bsp", spaces[0].Type)
+				assert.Equal(t, "stack", spaces[1].Type)
+			},
+		},
+		{
+			name:    "no match",
+			command: `[title="nope"] fullscreen; workspace 2`,
+			check: func(t *testing.T, f *yabai.Fake) {
+				assert.Equal(t, "c", activeApp(t, f))
+			},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newTwoDisplayFake()
+			ctx := newTestContext(f)
+			commands, err := i3parser.ParseCommandString(tc.command)
+			if !assert.NoError(t, err) {
+				return
+			}
+			for _, err := range Commands(ctx, commands, noChangeMode, noRestart) {
+				assert.NoError(t, err)
+			}
+			tc.check(t, f)
+		})
+	}
+}
+
+func TestCommands_criteriaWorkspace(t *testing.T) {
+	f := newTwoDisplayFake()
+	f.AddSpace(2)
+	ctx := newTestContext(f)
+	commands, err := i3parser.ParseCommandString(`[class="^c$"] move workspace to output left`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, err := range Commands(ctx, commands, noChangeMode, noRestart) {
+		assert.NoError(t, err)
+	}
+
+	// the workspace holding c moved, not the focused one
+	spaces, err := f.QuerySpaces()
+	assert.NoError(t, err)
+	displays := map[int]int{}
+	for _, s := range spaces {
+		displays[s.DisplayIndex]++
+	}
+	assert.Equal(t, map[int]int{1: 2, 2: 1}, displays)
+	assert.Equal(t, "a", activeApp(t, f))
+	w, err := f.QueryWindows()
+	assert.NoError(t, err)
+	for _, window := range w {
+		if window.App == "c" {
+			assert.Equal(t, 1, window.Display)
+		}
+	}
+}
+
+func TestCommands_invalidCriteria(t *testing.T) {
+	ctx := newTestContext(newTwoDisplayFake())
+	commands, err := i3parser.ParseCommandString(`[title="("] focus, fullscreen`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	errs := Commands(ctx, commands, noChangeMode, noRestart)
+	if assert.Len(t, errs, 2) {
+		assert.Error(t, errs[0])
+		assert.Error(t, errs[1])
+	}
+}
@@ -0,0 +1,242 @@
+package run
+
+import (
+	"fmt"
+	"regexp"
+	"strconv"
+	"strings"
+
+	"github.com/abibby/yabai3/i3parser"
+	"github.com/abibby/yabai3/yabai"
+)
+
+// focusedValue matches the value of the same criterion on the focused window.
+const focusedValue = "__focused__"
+
+// windowTypes maps i3's window_type values to the macOS subroles that behave
+// the same way.
+var windowTypes = map[string][]string{
+	"normal":  {"AXStandardWindow"},
+	"dialog":  {"AXDialog", "AXSystemDialog"},
+	"utility": {"AXFloatingWindow", "AXSystemFloatingWindow"},
+}
+
+type criterion func(w *yabai.Window) bool
+
+// MatchWindows returns every window that matches all of the conditions.
+func MatchWindows(y yabai.Client, marks *Marks, conditions *i3parser.Conditions) ([]*yabai.Window, error) {
+	match, err := newMatcher(y, marks, conditions)
+	if err != nil {
+		return nil, err
+	}
+	windows, err := y.QueryWindows()
+	if err != nil {
+		return nil, err
+	}
+
+	matches := []*yabai.Window{}
+	for _, w := range windows {
+		if match(w) {
+			matches = append(matches, w)
+		}
+	}
+	return matches, nil
+}
+
+// MatchWindow reports whether w matches all of the conditions.
+func MatchWindow(y yabai.Client, marks *Marks, conditions *i3parser.Conditions, w *yabai.Window) (bool, error) {
+	match, err := newMatcher(y, marks, conditions)
+	if err != nil {
+		return false, err
+	}
+	return match(w), nil
+}
+
+func newMatcher(y yabai.Client, marks *Marks, conditions *i3parser.Conditions) (criterion, error) {
+	spaces, err := y.QuerySpaces()
+	if err != nil {
+		return nil, err
+	}
+	// there is no focused window on an empty space, __focused__ then matches
+	// nothing
+	focused, _ := y.QueryActiveWindow()
+
+	spaceNames := map[int]string{}
+	for _, s := range spaces {
+		spaceNames[s.Index] = spaceName(s)
+	}
+
+	criteria := make([]criterion, len(conditions.Conditions))
+	for i, c := range conditions.Conditions {
+		criteria[i], err = newCriterion(c, focused, spaceNames, marks)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return func(w *yabai.Window) bool {
+		return matchAll(criteria, w)
+	}, nil
+}
+
+func matchAll(criteria []criterion, w *yabai.Window) bool {
+	for _, c := range criteria {
+		if !c(w) {
+			return false
+		}
+	}
+	return true
+}
+
+func newCriterion(c *i3parser.Condition, focused *yabai.Window, spaceNames map[int]string, marks *Marks) (criterion, error) {
+	name := c.Type.Value
+	switch name {
+	case "floating":
+		return func(w *yabai.Window) bool { return w.IsFloating }, nil
+	case "tiling":
+		return func(w *yabai.Window) bool { return !w.IsFloating }, nil
+	}
+
+	if c.Value == nil {
+		return nil, fmt.Errorf("criterion %s requires a value", name)
+	}
+	value := c.Value.Value
+
+	switch name {
+	case "class", "instance", "app":
+		return stringCriterion(value, focused, func(w *yabai.Window) string { return w.App })
+	case "title":
+		return stringCriterion(value, focused, func(w *yabai.Window) string { return w.Title })
+	case "window_role":
+		return stringCriterion(value, focused, func(w *yabai.Window) string { return w.Role })
+	case "workspace":
+		return stringCriterion(value, focused, func(w *yabai.Window) string { return spaceNames[w.Space] })
+	case "con_mark":
+		if value == focusedValue {
+			return nil, fmt.Errorf("con_mark does not support %s", focusedValue)
+		}
+		re, err := regexp.Compile(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid regular expression %s: %w", value, err)
+		}
+		return func(w *yabai.Window) bool {
+			for _, mark := range marks.Window(w.ID) {
+				if re.MatchString(mark) {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case "window_type":
+		subroles, ok := windowTypes[value]
+		if !ok {
+			subroles = []string{value}
+		}
+		return func(w *yabai.Window) bool {
+			for _, s := range subroles {
+				if w.Subrole == s {
+					return true
+				}
+			}
+			return false
+		}, nil
+	case "id", "con_id":
+		if value == focusedValue {
+			return func(w *yabai.Window) bool { return focused != nil && w.ID == focused.ID }, nil
+		}
+		id, err := strconv.Atoi(value)
+		if err != nil {
+			return nil, fmt.Errorf("invalid %s %s: %w", name, value, err)
+		}
+		return func(w *yabai.Window) bool { return w.ID == id }, nil
+	}
+	return nil, fmt.Errorf("unsupported criterion %s", name)
+}
+
+// stringCriterion matches the field returned by get against the regular
+// expression pattern, or against the focused window's field for __focused__.
+func stringCriterion(pattern string, focused *yabai.Window, get func(w *yabai.Window) string) (criterion, error) {
+	if pattern == focusedValue {
+		return func(w *yabai.Window) bool {
+			return focused != nil && get(w) == get(focused)
+		}, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, fmt.Errorf("invalid regular expression %s: %w", pattern, err)
+	}
+	return func(w *yabai.Window) bool {
+		return re.MatchString(get(w))
+	}, nil
+}
+
+// windowClient runs window messages against a single window instead of the
+// focused one.
+type windowClient struct {
+	yabai.Client
+	window *yabai.Window
+}
+
+// Yabai adds the window to window messages and the window's space to space
+// messages that don't select one. New spaces are still created on the display
+// they name.
+func (c *windowClient) Yabai(args ...string) error {
+	if len(args) > 0 && (len(args) == 1 || strings.HasPrefix(args[1], "--")) {
+		switch args[0] {
+		case "window":
+			args = append([]string{"window", strconv.Itoa(c.window.ID)}, args[1:]...)
+		case "space":
+			if len(args) > 1 && args[1] == "--create" {
+				break
+			}
+			s, err := c.QueryActiveSpace()
+			if err != nil {
+				return err
+			}
+			args = append([]string{"space", strconv.Itoa(s.Index)}, args[1:]...)
+		}
+	}
+	return c.Client.Yabai(args...)
+}
+
+// QueryActiveSpace returns the space that holds the window, so workspace
+// commands act on the matched window's workspace.
+func (c *windowClient) QueryActiveSpace() (*yabai.Space, error) {
+	w, err := c.QueryActiveWindow()
+	if err != nil {
+		return nil, err
+	}
+	spaces, err := c.Client.QuerySpaces()
+	if err != nil {
+		return nil, err
+	}
+	for _, s := range spaces {
+		if s.Index == w.Space {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("no space %d", w.Space)
+}
+
+// focusedClient returns the client for the focused window and space, without
+// the scope of criteria.
+func focusedClient(y yabai.Client) yabai.Client {
+	if c, ok := y.(*windowClient); ok {
+		return c.Client
+	}
+	return y
+}
+
+// QueryActiveWindow queries the window again so commands later in a chain
+// see the changes made by the ones before them.
+func (c *windowClient) QueryActiveWindow() (*yabai.Window, error) {
+	windows, err := c.Client.QueryWindows()
+	if err != nil {
+		return nil, err
+	}
+	for _, w := range windows {
+		if w.ID == c.window.ID {
+			return w, nil
+		}
+	}
+	return nil, fmt.Errorf("window %d no longer exists", c.window.ID)
+}
@@ -0,0 +1,145 @@
+package run
+
+import (
+	"fmt"
+	"math"
+	"strconv"
+
+	"github.com/abibby/yabai3/yabai"
+)
+
+// i3's defaults for floating_minimum_size.
+const (
+	defaultFloatingMinimumWidth  = 75
+	defaultFloatingMinimumHeight = 50
+)
+
+func runFloating(o *Options) runner {
+	return func(y yabai.Client, c []string) error {
+		if len(c) != 2 {
+			return ErrUnknownCommand
+		}
+		w, err := y.QueryActiveWindow()
+		if err != nil {
+			return err
+		}
+		switch c[1] {
+		case "toggle":
+		case "enable":
+			if w.IsFloating {
+				return nil
+			}
+		case "disable":
+			if !w.IsFloating {
+				return nil
+			}
+		default:
+			return ErrUnknownCommand
+		}
+		err = y.Yabai("window", "--toggle", "float")
+		if err != nil {
+			return err
+		}
+		if w.IsFloating {
+			return nil
+		}
+		return centerFloating(y, o, w.ID, w.Display)
+	}
+}
+
+// centerFloating gives a window that was just floated half of the display,
+// within floating_minimum_size and floating_maximum_size, and centers it.
+func centerFloating(y yabai.Client, o *Options, windowID, displayIndex int) error {
+	d, err := getDisplay(y, displayIndex)
+	if err != nil {
+		return err
+	}
+	min, max := o.floatingSize()
+	width := floatingLimit(d.Frame.Width/2, d.Frame.Width, min.Width, max.Width, defaultFloatingMinimumWidth)
+	height := floatingLimit(d.Frame.Height/2, d.Frame.Height, min.Height, max.Height, defaultFloatingMinimumHeight)
+
+	x := d.Frame.X + (d.Frame.Width-width)/2
+	top := d.Frame.Y + (d.Frame.Height-height)/2
+	id := strconv.Itoa(windowID)
+	err = y.Yabai("window", id, "--move", fmt.Sprintf("abs:%d:%d", int(x), int(top)))
+	if err != nil {
+		return err
+	}
+	return y.Yabai("window", id, "--resize", fmt.Sprintf("abs:%d:%d", int(width), int(height)))
+}
+
+// floatingLimit clamps size between the configured limits. A limit of 0 uses
+// the default, the minimum defaults to defaultMin and the maximum to the size
+// of the display, and -1 removes the limit.
+func floatingLimit(size, display float32, min, max, defaultMin int) float32 {
+	lo := float32(min)
+	switch min {
+	case 0:
+		lo = float32(defaultMin)
+	case -1:
+		lo = 0
+	}
+	hi := float32(max)
+	switch max {
+	case 0:
+		hi = display
+	case -1:
+		hi = math.MaxFloat32
+	}
+	return float32(math.Max(float64(lo), math.Min(float64(size), float64(hi))))
+}
+
+func runSticky(y yabai.Client, c []string) error {
+	if len(c) != 2 {
+		return ErrUnknownCommand
+	}
+	w, err := y.QueryActiveWindow()
+	if err != nil {
+		return err
+	}
+	switch c[1] {
+	case "toggle":
+	case "enable":
+		if w.IsSticky {
+			return nil
+		}
+	case "disable":
+		if !w.IsSticky {
+			return nil
+		}
+	default:
+		return ErrUnknownCommand
+	}
+	return y.Yabai("window", "--toggle", "sticky")
+}
+
+// focusMode focuses a floating or tiling window on the same space as the
+// focused window. mode_toggle switches between the two.
+func focusMode(y yabai.Client, mode string) error {
+	active, err := y.QueryActiveWindow()
+	if err != nil {
+		return err
+	}
+	floating := mode == "floating"
+	if mode == "mode_toggle" {
+		floating = !active.IsFloating
+	}
+	if active.IsFloating == floating {
+		return nil
+	}
+
+	windows, err := y.QueryWindows()
+	if err != nil {
+		return err
+	}
+	for _, w := range windows {
+		if w.Space != active.Space || w.IsMinimized || w.IsHidden || w.IsFloating != floating {
+			continue
+		}
+		return y.Yabai("window", "--focus", strconv.Itoa(w.ID))
+	}
+	if floating {
+		return fmt.Errorf("no floating window on this workspace")
+	}
+	return fmt.Errorf("no tiling window on this workspace")
+}
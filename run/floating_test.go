@@ -0,0 +1,79 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/abibby/salusa/di"
+	"github.com/abibby/yabai3/config"
+	"github.com/abibby/yabai3/yabai"
+	"github.com/stretchr/testify/assert"
+)
+
+func activeWindow(t *testing.T, f *yabai.Fake) *yabai.Window {
+	w, err := f.QueryActiveWindow()
+	if err != nil {
+		t.Fatal(err)
+	}
+	return w
+}
+
+func TestFloating(t *testing.T) {
+	f := newTwoDisplayFake()
+	ctx := newTestContext(f)
+
+	assert.NoError(t, Command(ctx, []string{"floating", "enable"}, noChangeMode, noRestart))
+	w := activeWindow(t, f)
+	assert.True(t, w.IsFloating)
+	assert.Equal(t, &yabai.Frame{X: 250, Y: 200, Width: 500, Height: 400}, w.Frame)
+
+	// enabling it again leaves the window where it is
+	assert.NoError(t, Command(ctx, []string{"floating", "enable"}, noChangeMode, noRestart))
+	assert.True(t, activeWindow(t, f).IsFloating)
+
+	assert.NoError(t, Command(ctx, []string{"floating", "toggle"}, noChangeMode, noRestart))
+	assert.False(t, activeWindow(t, f).IsFloating)
+}
+
+func TestFloating_sizeLimits(t *testing.T) {
+	f := newTwoDisplayFake()
+	ctx := newTestContext(f)
+	options, err := di.Resolve[*Options](ctx)
+	assert.NoError(t, err)
+	options.Load(&config.Config{
+		FloatingMinimumSize: config.Size{Width: 600, Height: -1},
+		FloatingMaximumSize: config.Size{Width: -1, Height: 300},
+	})
+
+	assert.NoError(t, Command(ctx, []string{"floating", "toggle"}, noChangeMode, noRestart))
+	assert.Equal(t, &yabai.Frame{X: 200, Y: 250, Width: 600, Height: 300}, activeWindow(t, f).Frame)
+}
+
+func TestSticky(t *testing.T) {
+	f := newTwoDisplayFake()
+	ctx := newTestContext(f)
+
+	assert.NoError(t, Command(ctx, []string{"sticky", "toggle"}, noChangeMode, noRestart))
+	assert.True(t, activeWindow(t, f).IsSticky)
+	assert.NoError(t, Command(ctx, []string{"sticky", "enable"}, noChangeMode, noRestart))
+	assert.True(t, activeWindow(t, f).IsSticky)
+	assert.NoError(t, Command(ctx, []string{"sticky", "disable"}, noChangeMode, noRestart))
+	assert.False(t, activeWindow(t, f).IsSticky)
+}
+
+func TestFocusMode(t *testing.T) {
+	f := newTwoDisplayFake()
+	ctx := newTestContext(f)
+
+	assert.Error(t, Command(ctx, []string{"focus", "floating"}, noChangeMode, noRestart))
+
+	assert.NoError(t, Command(ctx, []string{"focus", "right"}, noChangeMode, noRestart))
+	assert.NoError(t, Command(ctx, []string{"floating", "enable"}, noChangeMode, noRestart))
+	assert.Equal(t, "b", activeApp(t, f))
+
+	assert.NoError(t, Command(ctx, []string{"focus", "mode_toggle"}, noChangeMode, noRestart))
+	assert.Equal(t, "a", activeApp(t, f))
+	assert.NoError(t, Command(ctx, []string{"focus", "floating"}, noChangeMode, noRestart))
+	assert.Equal(t, "b", activeApp(t, f))
+	assert.NoError(t, Command(ctx, []string{"focus", "tiling"}, noChangeMode, noRestart))
+	assert.Equal(t, "a", activeApp(t, f))
+}
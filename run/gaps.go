@@ -3,29 +3,28 @@ package run
 import (
 	"fmt"
 
+	"github.com/abibby/yabai3/config"
 	"github.com/abibby/yabai3/yabai"
 )
 
-func SetGaps(inner, outer int) error {
-	err := yabai.Yabai("config", "window_gap", fmt.Sprint(inner))
-	if err != nil {
-		return err
+// SetGaps sets the gap between windows and the padding on each edge of every
+// space.
+func SetGaps(y yabai.Client, gaps *config.Gaps) error {
+	settings := []struct {
+		name  string
+		value int
+	}{
+		{"window_gap", gaps.Inner},
+		{"top_padding", gaps.Top},
+		{"bottom_padding", gaps.Bottom},
+		{"left_padding", gaps.Left},
+		{"right_padding", gaps.Right},
 	}
-	err = yabai.Yabai("config", "top_padding", fmt.Sprint(outer))
-	if err != nil {
-		return err
-	}
-	err = yabai.Yabai("config", "bottom_padding", fmt.Sprint(outer))
-	if err != nil {
-		return err
-	}
-	err = yabai.Yabai("config", "left_padding", fmt.Sprint(outer))
-	if err != nil {
-		return err
-	}
-	err = yabai.Yabai("config", "right_padding", fmt.Sprint(outer))
-	if err != nil {
-		return err
+	for _, s := range settings {
+		err := y.Yabai("config", s.name, fmt.Sprint(s.value))
+		if err != nil {
+			return err
+		}
 	}
 	return nil
 }
@@ -0,0 +1,98 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+	"log"
+	"strconv"
+	"syscall"
+	"time"
+
+	"github.com/abibby/yabai3/yabai"
+)
+
+const (
+	defaultKillTimeout = 3 * time.Second
+	killPollInterval   = 50 * time.Millisecond
+)
+
+// runKill implements `kill [window|client]`. kill closes only the targeted
+// window, kill client quits the whole application.
+func runKill(o *Options) runner {
+	return func(y yabai.Client, c []string) error {
+		if len(c) > 2 {
+			return ErrUnknownCommand
+		}
+		mode := "window"
+		if len(c) == 2 {
+			mode = c[1]
+		}
+		if mode != "window" && mode != "client" {
+			return ErrUnknownCommand
+		}
+
+		w, err := y.QueryActiveWindow()
+		if err != nil {
+			return err
+		}
+		if mode == "client" {
+			return killClient(w.PID, o.clientKillTimeout(), func() bool {
+				return ownsWindow(y, w.PID, w.App)
+			})
+		}
+		err = y.Yabai("window", strconv.Itoa(w.ID), "--close")
+		if err != nil {
+			return fmt.Errorf("could not close window %d: %w", w.ID, err)
+		}
+		return nil
+	}
+}
+
+// killClient asks a process to quit with SIGTERM and sends SIGKILL if it is
+// still running after timeout. It doesn't wait for the process to exit. The
+// pid can be reused once the process exits, SIGKILL is only sent while owned
+// reports that the pid still belongs to the client.
+func killClient(pid int, timeout time.Duration, owned func() bool) error {
+	// kill(2) signals a whole process group for 0 and negative pids,
+	// including yabai3's own for 0
+	if pid <= 0 {
+		return fmt.Errorf("invalid process id %d", pid)
+	}
+	err := syscall.Kill(pid, syscall.SIGTERM)
+	if err != nil {
+		return fmt.Errorf("could not terminate process %d: %w", pid, err)
+	}
+	go func() {
+		deadline := time.Now().Add(timeout)
+		for time.Now().Before(deadline) {
+			time.Sleep(killPollInterval)
+			if syscall.Kill(pid, 0) != nil {
+				return
+			}
+		}
+		if !owned() {
+			log.Printf("process %d no longer belongs to the client, not killing it", pid)
+			return
+		}
+		err := syscall.Kill(pid, syscall.SIGKILL)
+		if err != nil && !errors.Is(err, syscall.ESRCH) {
+			log.Printf("could not kill process %d: %v", pid, err)
+		}
+	}()
+	return nil
+}
+
+// ownsWindow reports whether yabai still has a window of app owned by pid.
+func ownsWindow(y yabai.Client, pid int, app string) bool {
+	windows, err := y.QueryWindows()
+	if err != nil {
+		log.Printf("could not check the owner of process %d: %v", pid, err)
+		return false
+	}
+	for _, w := range windows {
+		if w.PID == pid && w.App == app {
+			return true
+		}
+	}
+	return false
+}
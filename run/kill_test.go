@@ -0,0 +1,117 @@
+package run
+
+import (
+	"os/exec"
+	"testing"
+	"time"
+
+	"github.com/abibby/yabai3/i3parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestKill(t *testing.T) {
+	f := newTwoDisplayFake()
+	ctx := newTestContext(f)
+
+	assert.NoError(t, Command(ctx, []string{"kill"}, noChangeMode, noRestart))
+	commands, err := i3parser.ParseCommandString(`[app="c"] kill window`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, err := range Commands(ctx, commands, noChangeMode, noRestart) {
+		assert.NoError(t, err)
+	}
+
+	windows, err := f.QueryWindows()
+	assert.NoError(t, err)
+	if assert.Len(t, windows, 1) {
+		assert.Equal(t, "b", windows[0].App)
+	}
+
+	f.AddWindow(1, "d", "sheet").Role = "AXSheet"
+	assert.NoError(t, Command(ctx, []string{"focus", "right"}, noChangeMode, noRestart))
+	assert.Equal(t, "d", activeApp(t, f))
+	err = Command(ctx, []string{"kill"}, noChangeMode, noRestart)
+	assert.EqualError(t, err, "kill: could not close window 8: could not close window")
+}
+
+func TestKillClient(t *testing.T) {
+	testCases := []struct {
+		name   string
+		shell  string
+		owned  bool
+		exited bool
+	}{
+		{name: "terminate", shell: "exec sleep 5", owned: true, exited: true},
+		{name: "ignores SIGTERM", shell: `trap "" TERM; exec sleep 5`, owned: true, exited: true},
+		{name: "pid no longer owned", shell: `trap "" TERM; exec sleep 5`, owned: false, exited: false},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			cmd := exec.Command("sh", "-c", tc.shell)
+			if !assert.NoError(t, cmd.Start()) {
+				return
+			}
+			// give sh time to set up the trap
+			time.Sleep(100 * time.Millisecond)
+
+			exited := make(chan struct{})
+			go func() {
+				cmd.Wait()
+				close(exited)
+			}()
+			assert.NoError(t, killClient(cmd.Process.Pid, 200*time.Millisecond, func() bool { return tc.owned }))
+			t.Cleanup(func() { cmd.Process.Kill() })
+			timeout := 2 * time.Second
+			if !tc.exited {
+				timeout = 500 * time.Millisecond
+			}
+			select {
+			case <-exited:
+				assert.True(t, tc.exited, "process was killed")
+			case <-time.After(timeout):
+				assert.False(t, tc.exited, "process is still running")
+			}
+		})
+	}
+}
+
+func TestKillClient_criteria(t *testing.T) {
+	f := newTwoDisplayFake()
+	ctx := newTestContext(f)
+
+	cmd := exec.Command("sleep", "5")
+	if !assert.NoError(t, cmd.Start()) {
+		return
+	}
+	exited := make(chan struct{})
+	go func() {
+		cmd.Wait()
+		close(exited)
+	}()
+	f.AddWindow(2, "d", "d").PID = cmd.Process.Pid
+
+	// a is focused, kill client goes to the process of the matched window
+	commands, err := i3parser.ParseCommandString(`[app="d"] kill client`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, err := range Commands(ctx, commands, noChangeMode, noRestart) {
+		assert.NoError(t, err)
+	}
+	select {
+	case <-exited:
+	case <-time.After(2 * time.Second):
+		t.Fatal("the process of the matched window is still running")
+	}
+
+	f.AddWindow(1, "e", "e").PID = 0
+	commands, err = i3parser.ParseCommandString(`[app="e"] kill client`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	errs := Commands(ctx, commands, noChangeMode, noRestart)
+	if assert.Len(t, errs, 1) {
+		assert.EqualError(t, errs[0], "kill client: invalid process id 0")
+	}
+}
@@ -0,0 +1,209 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/abibby/salusa/di"
+	"github.com/abibby/yabai3/yabai"
+)
+
+// layoutNames maps the layouts accepted by the layout command to the names i3
+// reports in get_tree.
+var layoutNames = map[string]string{
+	"default":  "splith",
+	"splith":   "splith",
+	"splitv":   "splitv",
+	"stacking": "stacked",
+	"stacked":  "stacked",
+	"tabbed":   "tabbed",
+	"split":    "split",
+}
+
+type spaceLayout struct {
+	layout    string
+	lastSplit string
+}
+
+// Layouts remembers the i3 layout chosen for each space. yabai only knows if a
+// space is bsp or stack, so stacked and tabbed and the split direction are
+// tracked here.
+type Layouts struct {
+	mtx     *sync.Mutex
+	layouts map[int]*spaceLayout
+}
+
+func NewLayouts() *Layouts {
+	return &Layouts{
+		mtx:     &sync.Mutex{},
+		layouts: map[int]*spaceLayout{},
+	}
+}
+
+func RegisterLayouts(ctx context.Context, l *Layouts) {
+	di.RegisterSingleton(ctx, func() *Layouts {
+		return l
+	})
+}
+
+// Get returns the i3 layout of a space. Layouts that no longer match the
+// space's yabai layout, because it was changed outside of yabai3, are
+// ignored.
+func (l *Layouts) Get(s *yabai.Space) string {
+	tracked := l.get(s.ID)
+	if s.Type == "stack" {
+		if tracked.layout == "tabbed" {
+			return "tabbed"
+		}
+		return "stacked"
+	}
+	if tracked.layout == "splitv" {
+		return "splitv"
+	}
+	return "splith"
+}
+
+func (l *Layouts) get(spaceID int) spaceLayout {
+	if l == nil {
+		return spaceLayout{lastSplit: "splith"}
+	}
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	if sl, ok := l.layouts[spaceID]; ok {
+		return *sl
+	}
+	return spaceLayout{lastSplit: "splith"}
+}
+
+func (l *Layouts) set(spaceID int, layout string) {
+	l.mtx.Lock()
+	defer l.mtx.Unlock()
+	sl, ok := l.layouts[spaceID]
+	if !ok {
+		sl = &spaceLayout{lastSplit: "splith"}
+		l.layouts[spaceID] = sl
+	}
+	sl.layout = layout
+	if layout == "splith" || layout == "splitv" {
+		sl.lastSplit = layout
+	}
+}
+
+func runLayout(l *Layouts) runner {
+	return func(y yabai.Client, c []string) error {
+		if len(c) < 2 {
+			return ErrUnknownCommand
+		}
+		s, err := commandSpace(y)
+		if err != nil {
+			return err
+		}
+		current := l.Get(s)
+		lastSplit := l.get(s.ID).lastSplit
+
+		if c[1] != "toggle" {
+			layout, ok := layoutNames[c[1]]
+			if !ok || len(c) != 2 || layout == "split" {
+				return ErrUnknownCommand
+			}
+			return setLayout(y, l, s, layout)
+		}
+
+		var cycle []string
+		switch {
+		case len(c) == 2:
+			cycle = []string{"stacked", "tabbed", lastSplit}
+		case len(c) == 3 && c[2] == "split":
+			cycle = []string{"splith", "splitv"}
+		case len(c) == 3 && c[2] == "all":
+			cycle = []string{"stacked", "tabbed", "splith", "splitv"}
+		default:
+			for _, name := range c[2:] {
+				layout, ok := layoutNames[name]
+				if !ok {
+					return ErrUnknownCommand
+				}
+				if layout == "split" {
+					layout = lastSplit
+				}
+				cycle = append(cycle, layout)
+			}
+		}
+
+		next := cycle[0]
+		if i := slices.Index(cycle, current); i != -1 {
+			next = cycle[(i+1)%len(cycle)]
+		}
+		return setLayout(y, l, s, next)
+	}
+}
+
+// setLayout switches the space to the yabai layout for an i3 layout. The split
+// direction is applied by setting where yabai inserts the next window next
+// to the focused one.
+func setLayout(y yabai.Client, l *Layouts, s *yabai.Space, layout string) error {
+	spaceType := "bsp"
+	if layout == "stacked" || layout == "tabbed" {
+		spaceType = "stack"
+	}
+	if s.Type != spaceType {
+		err := y.Yabai("space", strconv.Itoa(s.Index), "--layout", spaceType)
+		if err != nil {
+			return err
+		}
+	}
+	l.set(s.ID, layout)
+
+	if spaceType == "bsp" && len(s.WindowIDs) > 0 {
+		return insert(y, layout)
+	}
+	return nil
+}
+
+func insert(y yabai.Client, layout string) error {
+	if layout == "splitv" {
+		return y.Yabai("window", "--insert", "south")
+	}
+	return y.Yabai("window", "--insert", "east")
+}
+
+func runSplit(y yabai.Client, c []string) error {
+	if len(c) != 2 {
+		return ErrUnknownCommand
+	}
+	switch c[1] {
+	case "h", "horizontal":
+		return insert(y, "splith")
+	case "v", "vertical":
+		return insert(y, "splitv")
+	case "t", "toggle":
+		return y.Yabai("window", "--toggle", "split")
+	}
+	return ErrUnknownCommand
+}
+
+// commandSpace returns the space of the window a command acts on, or the
+// focused space if no window has focus.
+func commandSpace(y yabai.Client) (*yabai.Space, error) {
+	spaces, err := y.QuerySpaces()
+	if err != nil {
+		return nil, err
+	}
+	w, err := y.QueryActiveWindow()
+	if err == nil {
+		for _, s := range spaces {
+			if s.Index == w.Space {
+				return s, nil
+			}
+		}
+	}
+	for _, s := range spaces {
+		if s.HasFocus {
+			return s, nil
+		}
+	}
+	return nil, fmt.Errorf("no focused space")
+}
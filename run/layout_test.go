@@ -0,0 +1,67 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/abibby/salusa/di"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestLayout(t *testing.T) {
+	testCases := []struct {
+		commands  [][]string
+		layout    string
+		spaceType string
+	}{
+		{commands: [][]string{{"layout", "tabbed"}}, layout: "tabbed", spaceType: "stack"},
+		{commands: [][]string{{"layout", "stacking"}}, layout: "stacked", spaceType: "stack"},
+		{commands: [][]string{{"layout", "tabbed"}, {"layout", "default"}}, layout: "splith", spaceType: "bsp"},
+		{commands: [][]string{{"layout", "toggle", "split"}}, layout: "splitv", spaceType: "bsp"},
+		{commands: [][]string{{"layout", "toggle", "split"}, {"layout", "toggle", "split"}}, layout: "splith", spaceType: "bsp"},
+		{commands: [][]string{{"layout", "toggle"}}, layout: "stacked", spaceType: "stack"},
+		{commands: [][]string{{"layout", "splitv"}, {"layout", "tabbed"}, {"layout", "toggle"}}, layout: "splitv", spaceType: "bsp"},
+		{commands: [][]string{{"layout", "tabbed"}, {"layout", "toggle", "all"}}, layout: "splith", spaceType: "bsp"},
+		{commands: [][]string{{"layout", "toggle", "tabbed", "split"}}, layout: "tabbed", spaceType: "stack"},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.layout, func(t *testing.T) {
+			f := newTwoDisplayFake()
+			ctx := newTestContext(f)
+			for _, c := range tc.commands {
+				assert.NoError(t, Command(ctx, c, noChangeMode, noRestart))
+			}
+
+			layouts, err := di.Resolve[*Layouts](ctx)
+			assert.NoError(t, err)
+			s, err := f.QueryActiveSpace()
+			assert.NoError(t, err)
+			assert.Equal(t, tc.layout, layouts.Get(s))
+			assert.Equal(t, tc.spaceType, s.Type)
+		})
+	}
+}
+
+func TestLayout_changedOutsideYabai3(t *testing.T) {
+	f := newTwoDisplayFake()
+	ctx := newTestContext(f)
+	assert.NoError(t, Command(ctx, []string{"layout", "tabbed"}, noChangeMode, noRestart))
+	assert.NoError(t, f.Yabai("space", "--layout", "bsp"))
+
+	layouts, err := di.Resolve[*Layouts](ctx)
+	assert.NoError(t, err)
+	s, err := f.QueryActiveSpace()
+	assert.NoError(t, err)
+	assert.Equal(t, "splith", layouts.Get(s))
+}
+
+func TestSplit(t *testing.T) {
+	f := newTwoDisplayFake()
+	ctx := newTestContext(f)
+	assert.NoError(t, Command(ctx, []string{"split", "v"}, noChangeMode, noRestart))
+	assert.NoError(t, Command(ctx, []string{"split", "toggle"}, noChangeMode, noRestart))
+	assert.Error(t, Command(ctx, []string{"split", "sideways"}, noChangeMode, noRestart))
+
+	messages := f.Messages()
+	assert.Contains(t, messages, []string{"window", "--insert", "south"})
+	assert.Contains(t, messages, []string{"window", "--toggle", "split"})
+}
@@ -0,0 +1,190 @@
+package run
+
+import (
+	"context"
+	"fmt"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/abibby/salusa/di"
+	"github.com/abibby/yabai3/yabai"
+)
+
+// Marks maps each mark to the id of the window it is on. Like i3 a mark can
+// only be on one window at a time.
+type Marks struct {
+	mtx   *sync.Mutex
+	marks map[string]int
+}
+
+func NewMarks() *Marks {
+	return &Marks{
+		mtx:   &sync.Mutex{},
+		marks: map[string]int{},
+	}
+}
+
+func RegisterMarks(ctx context.Context, m *Marks) {
+	di.RegisterSingleton(ctx, func() *Marks {
+		return m
+	})
+}
+
+// List returns every mark in alphabetical order.
+func (m *Marks) List() []string {
+	if m == nil {
+		return []string{}
+	}
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	marks := make([]string, 0, len(m.marks))
+	for mark := range m.marks {
+		marks = append(marks, mark)
+	}
+	slices.Sort(marks)
+	return marks
+}
+
+// Window returns the marks on a window in alphabetical order.
+func (m *Marks) Window(id int) []string {
+	if m == nil {
+		return []string{}
+	}
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	marks := []string{}
+	for mark, windowID := range m.marks {
+		if windowID == id {
+			marks = append(marks, mark)
+		}
+	}
+	slices.Sort(marks)
+	return marks
+}
+
+// Get returns the window with the mark.
+func (m *Marks) Get(mark string) (int, bool) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	id, ok := m.marks[mark]
+	return id, ok
+}
+
+// RemoveWindow removes every mark on a window, used when it is closed.
+func (m *Marks) RemoveWindow(id int) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	for mark, windowID := range m.marks {
+		if windowID == id {
+			delete(m.marks, mark)
+		}
+	}
+}
+
+func (m *Marks) add(id int, mark string, replace bool) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	if replace {
+		for other, windowID := range m.marks {
+			if windowID == id {
+				delete(m.marks, other)
+			}
+		}
+	}
+	m.marks[mark] = id
+}
+
+func (m *Marks) remove(mark string) {
+	m.mtx.Lock()
+	defer m.mtx.Unlock()
+	delete(m.marks, mark)
+}
+
+// runMark implements `mark [--add|--replace] [--toggle] <identifier>`.
+func runMark(m *Marks) runner {
+	return func(y yabai.Client, c []string) error {
+		replace := true
+		toggle := false
+		var mark string
+		for _, arg := range c[1:] {
+			switch arg {
+			case "--add":
+				replace = false
+			case "--replace":
+				replace = true
+			case "--toggle":
+				toggle = true
+			default:
+				if mark != "" {
+					return ErrUnknownCommand
+				}
+				mark = arg
+			}
+		}
+		if mark == "" {
+			return ErrUnknownCommand
+		}
+
+		w, err := y.QueryActiveWindow()
+		if err != nil {
+			return err
+		}
+		if id, ok := m.Get(mark); toggle && ok && id == w.ID {
+			m.remove(mark)
+			return nil
+		}
+		m.add(w.ID, mark, replace)
+		return nil
+	}
+}
+
+// runUnmark removes a mark, or every mark if none is given. With criteria
+// only marks on the matching windows are removed.
+func runUnmark(m *Marks) runner {
+	return func(y yabai.Client, c []string) error {
+		if len(c) > 2 {
+			return ErrUnknownCommand
+		}
+		target, scoped := y.(*windowClient)
+		if len(c) == 1 {
+			if scoped {
+				m.RemoveWindow(target.window.ID)
+				return nil
+			}
+			for _, mark := range m.List() {
+				m.remove(mark)
+			}
+			return nil
+		}
+
+		id, ok := m.Get(c[1])
+		if ok && (!scoped || id == target.window.ID) {
+			m.remove(c[1])
+		}
+		return nil
+	}
+}
+
+// runSwap implements `swap container with mark|con_id|id <value>`.
+func runSwap(m *Marks) runner {
+	return func(y yabai.Client, c []string) error {
+		if len(c) != 5 || c[1] != "container" || c[2] != "with" {
+			return ErrUnknownCommand
+		}
+		switch c[3] {
+		case "mark":
+			id, ok := m.Get(c[4])
+			if !ok {
+				return fmt.Errorf("no window with mark %s", c[4])
+			}
+			return y.Yabai("window", "--swap", strconv.Itoa(id))
+		case "con_id", "id":
+			if _, err := strconv.Atoi(c[4]); err != nil {
+				return fmt.Errorf("invalid %s %s", c[3], c[4])
+			}
+			return y.Yabai("window", "--swap", c[4])
+		}
+		return ErrUnknownCommand
+	}
+}
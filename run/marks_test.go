@@ -0,0 +1,91 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/abibby/salusa/di"
+	"github.com/abibby/yabai3/i3parser"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMark(t *testing.T) {
+	f := newTwoDisplayFake()
+	ctx := newTestContext(f)
+	marks, err := di.Resolve[*Marks](ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+	a := window(t, f, "a")
+	b := window(t, f, "b")
+	run := func(c ...string) {
+		t.Helper()
+		assert.NoError(t, Command(ctx, c, noChangeMode, noRestart))
+	}
+
+	run("mark", "x")
+	run("mark", "--add", "y")
+	assert.Equal(t, []string{"x", "y"}, marks.Window(a.ID))
+
+	run("mark", "z")
+	assert.Equal(t, []string{"z"}, marks.Window(a.ID))
+
+	// a mark can only be on one window
+	run("focus", "right")
+	assert.Equal(t, "b", activeApp(t, f))
+	run("mark", "--add", "z")
+	assert.Empty(t, marks.Window(a.ID))
+	assert.Equal(t, []string{"z"}, marks.Window(b.ID))
+
+	run("mark", "--add", "--toggle", "z")
+	assert.Equal(t, []string{}, marks.List())
+
+	run("mark", "x")
+	run("mark", "--add", "y")
+	run("unmark", "x")
+	assert.Equal(t, []string{"y"}, marks.List())
+	run("unmark")
+	assert.Equal(t, []string{}, marks.List())
+
+	assert.ErrorIs(t, Command(ctx, []string{"mark"}, noChangeMode, noRestart), ErrUnknownCommand)
+}
+
+func TestMark_criteria(t *testing.T) {
+	f := newTwoDisplayFake()
+	ctx := newTestContext(f)
+	marks, err := di.Resolve[*Marks](ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+	c := window(t, f, "c")
+
+	commands, err := i3parser.ParseCommandString(`[app="c"] mark term; [con_mark="^te"] focus`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, err := range Commands(ctx, commands, noChangeMode, noRestart) {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []string{"term"}, marks.Window(c.ID))
+	assert.Equal(t, "c", activeApp(t, f))
+
+	marks.RemoveWindow(c.ID)
+	assert.Equal(t, []string{}, marks.List())
+}
+
+func TestSwap(t *testing.T) {
+	f := newTwoDisplayFake()
+	ctx := newTestContext(f)
+	before := *window(t, f, "b").Frame
+
+	commands, err := i3parser.ParseCommandString(`[app="b"] mark other; swap container with mark other`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, err := range Commands(ctx, commands, noChangeMode, noRestart) {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, before, *window(t, f, "a").Frame)
+
+	err = Command(ctx, []string{"swap", "container", "with", "mark", "missing"}, noChangeMode, noRestart)
+	assert.EqualError(t, err, "swap container with mark missing: no window with mark missing")
+}
@@ -0,0 +1,34 @@
+//go:build darwin && cgo
+
+package run
+
+/*
+#cgo LDFLAGS: -framework CoreGraphics
+#include <CoreGraphics/CoreGraphics.h>
+
+static CGPoint pointer_location(int *ok) {
+	CGEventRef event = CGEventCreate(NULL);
+	if (event == NULL) {
+		*ok = 0;
+		return CGPointZero;
+	}
+	CGPoint p = CGEventGetLocation(event);
+	CFRelease(event);
+	*ok = 1;
+	return p;
+}
+*/
+import "C"
+
+import "errors"
+
+// pointerLocation asks CoreGraphics where the mouse pointer is, in global
+// display coordinates with the origin at the top left like yabai's frames.
+func pointerLocation() (float32, float32, error) {
+	var ok C.int
+	p := C.pointer_location(&ok)
+	if ok == 0 {
+		return 0, 0, errors.New("could not read the mouse position")
+	}
+	return float32(p.x), float32(p.y), nil
+}
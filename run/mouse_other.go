@@ -0,0 +1,9 @@
+//go:build !darwin || !cgo
+
+package run
+
+import "errors"
+
+func pointerLocation() (float32, float32, error) {
+	return 0, 0, errors.New("the mouse position is only available on macOS")
+}
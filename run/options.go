@@ -0,0 +1,79 @@
+package run
+
+import (
+	"context"
+	"sync"
+	"time"
+
+	"github.com/abibby/salusa/di"
+	"github.com/abibby/yabai3/config"
+)
+
+// Options are the config settings commands depend on. The daemon registers a
+// single Options and loads each config into it, so reloads take effect
+// without rebuilding the runners.
+type Options struct {
+	mtx                 *sync.Mutex
+	floatingMinimumSize config.Size
+	floatingMaximumSize config.Size
+	autoBackAndForth    bool
+	workspaces          []*config.Workspace
+	killTimeout         time.Duration
+}
+
+func NewOptions() *Options {
+	return &Options{
+		mtx: &sync.Mutex{},
+	}
+}
+
+func RegisterOptions(ctx context.Context, o *Options) {
+	di.RegisterSingleton(ctx, func() *Options {
+		return o
+	})
+}
+
+// Load replaces the options with the settings from cfg.
+func (o *Options) Load(cfg *config.Config) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	o.floatingMinimumSize = cfg.FloatingMinimumSize
+	o.floatingMaximumSize = cfg.FloatingMaximumSize
+	o.autoBackAndForth = cfg.WorkspaceAutoBackAndForth
+	o.workspaces = cfg.Workspaces
+	o.killTimeout = cfg.KillTimeout
+}
+
+func (o *Options) floatingSize() (min, max config.Size) {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	return o.floatingMinimumSize, o.floatingMaximumSize
+}
+
+func (o *Options) workspaceAutoBackAndForth() bool {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	return o.autoBackAndForth
+}
+
+// workspace returns the workspace directive for name, nil if the config
+// doesn't declare it.
+func (o *Options) workspace(name string) *config.Workspace {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	for _, w := range o.workspaces {
+		if w.Name == name {
+			return w
+		}
+	}
+	return nil
+}
+
+func (o *Options) clientKillTimeout() time.Duration {
+	o.mtx.Lock()
+	defer o.mtx.Unlock()
+	if o.killTimeout == 0 {
+		return defaultKillTimeout
+	}
+	return o.killTimeout
+}
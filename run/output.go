@@ -0,0 +1,158 @@
+package run
+
+import (
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/abibby/yabai3/yabai"
+)
+
+// OutputName returns the i3 output name of a display. yabai's display indexes
+// change as displays are connected, the name comes from the display's UUID so
+// it stays the same.
+func OutputName(d *yabai.Display) string {
+	id, _, _ := strings.Cut(d.UUID, "-")
+	if id == "" {
+		return strconv.Itoa(d.Index)
+	}
+	return "display-" + strings.ToLower(id)
+}
+
+// findOutput resolves the output of an output command. Directions and
+// next/prev are relative to the focused display and wrap around at the edge,
+// anything else is looked up with getOutput. With several names the first one
+// that exists is used.
+func findOutput(y yabai.Client, names []string) (*yabai.Display, error) {
+	if len(names) == 0 {
+		return nil, ErrUnknownCommand
+	}
+	for _, name := range names {
+		var d *yabai.Display
+		var err error
+		if direction, ok := directionMap[name]; ok {
+			d, err = getDisplayInDirection(y, direction)
+			if err != nil {
+				d, err = wrapOutput(y, direction)
+			}
+		} else if name == "next" || name == "prev" {
+			d, err = cycleOutput(y, name)
+		} else {
+			d, err = getOutput(y, name)
+		}
+		if err == nil {
+			return d, nil
+		}
+	}
+	return nil, fmt.Errorf("no output %s", strings.Join(names, " "))
+}
+
+// wrapOutput returns the display furthest in the opposite direction, the
+// display a direction wraps around to when there is none next to the focused
+// one.
+func wrapOutput(y yabai.Client, direction string) (*yabai.Display, error) {
+	displays, err := y.QueryDisplays()
+	if err != nil {
+		return nil, err
+	}
+	active, err := y.QueryActiveSpace()
+	if err != nil {
+		return nil, err
+	}
+	current, err := getDisplay(y, active.DisplayIndex)
+	if err != nil {
+		return nil, err
+	}
+
+	// the position along the direction, smaller is further back
+	position := func(d *yabai.Display) float32 {
+		switch direction {
+		case "east":
+			return d.Frame.X
+		case "west":
+			return -d.Frame.X
+		case "north":
+			return d.Frame.Y
+		}
+		return -d.Frame.Y
+	}
+	var wrapped *yabai.Display
+	for _, d := range displays {
+		if position(d) >= position(current) {
+			continue
+		}
+		if wrapped == nil || position(d) < position(wrapped) {
+			wrapped = d
+		}
+	}
+	if wrapped == nil {
+		return nil, ErrNoDisplay
+	}
+	return wrapped, nil
+}
+
+func cycleOutput(y yabai.Client, direction string) (*yabai.Display, error) {
+	displays, err := y.QueryDisplays()
+	if err != nil {
+		return nil, err
+	}
+	active, err := y.QueryActiveSpace()
+	if err != nil {
+		return nil, err
+	}
+	i := active.DisplayIndex - 1
+	if direction == "next" {
+		i = (i + 1) % len(displays)
+	} else {
+		i = (i + len(displays) - 1) % len(displays)
+	}
+	return getDisplay(y, i+1)
+}
+
+// runFocusOutput implements `focus output <output>`.
+func runFocusOutput(y yabai.Client, names []string) error {
+	d, err := findOutput(y, names)
+	if err != nil {
+		return err
+	}
+	active, err := y.QueryActiveSpace()
+	if err != nil {
+		return err
+	}
+	if active.DisplayIndex == d.Index {
+		return nil
+	}
+	return y.Yabai("display", "--focus", strconv.Itoa(d.Index))
+}
+
+// moveToOutput implements `move container to output <output>`.
+func moveToOutput(y yabai.Client, names []string) error {
+	d, err := findOutput(y, names)
+	if err != nil {
+		return err
+	}
+	w, err := y.QueryActiveWindow()
+	if err != nil {
+		return err
+	}
+	if w.Display == d.Index {
+		return nil
+	}
+	return y.Yabai("window", "--display", strconv.Itoa(d.Index))
+}
+
+// moveWorkspaceToOutput implements `move workspace to output <output>`.
+func moveWorkspaceToOutput(y yabai.Client, names []string) error {
+	d, err := findOutput(y, names)
+	if err != nil {
+		return err
+	}
+	active, err := y.QueryActiveSpace()
+	if err != nil {
+		return err
+	}
+	if active.DisplayIndex == d.Index {
+		return nil
+	}
+	return y.Yabai("space", "--display", strconv.Itoa(d.Index))
+}
@@ -0,0 +1,66 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/abibby/yabai3/yabai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestOutputName(t *testing.T) {
+	assert.Equal(t, "display-37d8832a", OutputName(&yabai.Display{Index: 2, UUID: "37D8832A-2D66-02CA-B9F7-8F30A301B230"}))
+	assert.Equal(t, "2", OutputName(&yabai.Display{Index: 2}))
+}
+
+func TestFocusOutput(t *testing.T) {
+	f := newTwoDisplayFake()
+	ctx := newTestContext(f)
+	displays, err := f.QueryDisplays()
+	if !assert.NoError(t, err) {
+		return
+	}
+	focus := func(output ...string) int {
+		t.Helper()
+		assert.NoError(t, Command(ctx, append([]string{"focus", "output"}, output...), noChangeMode, noRestart))
+		s, err := f.QueryActiveSpace()
+		assert.NoError(t, err)
+		return s.DisplayIndex
+	}
+
+	assert.Equal(t, 2, focus("right"))
+	// directions wrap around
+	assert.Equal(t, 1, focus("right"))
+	assert.Equal(t, 2, focus("next"))
+	assert.Equal(t, 1, focus("next"))
+	assert.Equal(t, 2, focus(OutputName(displays[1])))
+	assert.Equal(t, 1, focus("missing", "1"))
+
+	err = Command(ctx, []string{"focus", "output", "up"}, noChangeMode, noRestart)
+	assert.EqualError(t, err, "focus output up: no output up")
+}
+
+func TestMoveToOutput(t *testing.T) {
+	f := newTwoDisplayFake()
+	f.AddSpace(1)
+	ctx := newTestContext(f)
+
+	assert.NoError(t, Command(ctx, []string{"move", "container", "to", "output", "right"}, noChangeMode, noRestart))
+	assert.Equal(t, 3, windowSpace(t, f, "a"))
+
+	assert.NoError(t, Command(ctx, []string{"move", "workspace", "to", "output", "right"}, noChangeMode, noRestart))
+	s, err := f.QueryActiveSpace()
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, 2, s.DisplayIndex)
+	assert.Equal(t, 2, windowSpace(t, f, "b"))
+	assert.Equal(t, "b", activeApp(t, f))
+}
+
+func TestGetDisplayFrom_noDisplays(t *testing.T) {
+	f := yabai.NewFake()
+	for _, name := range []string{"left", "center", "right", "primary"} {
+		_, err := getDisplayFrom(f, []string{name})
+		assert.ErrorIs(t, err, ErrNoDisplay, name)
+	}
+}
@@ -0,0 +1,155 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+	"math"
+
+	"github.com/abibby/yabai3/yabai"
+)
+
+var ErrNotFloating = errors.New("only floating windows can be positioned")
+
+// mouseLocation returns the position of the mouse pointer in the same
+// coordinates as yabai's frames.
+var mouseLocation = pointerLocation
+
+// nudgeFloating moves a floating window in a direction, `move left 20 px`.
+// Without an amount it moves 10 px, ppt is relative to the display.
+func nudgeFloating(y yabai.Client, w *yabai.Window, direction string, args []string) error {
+	amount, rest := parseResizeAmount(args)
+	if len(rest) > 0 {
+		return ErrUnknownCommand
+	}
+	if !amount.hasPx && !amount.hasPpt {
+		amount = &resizeAmount{px: 10, hasPx: true}
+	}
+	d, err := getDisplay(y, w.Display)
+	if err != nil {
+		return err
+	}
+
+	dx, dy := float32(0), float32(0)
+	switch direction {
+	case "east":
+		dx = amount.pixels(true, d.Frame.Width)
+	case "west":
+		dx = -amount.pixels(true, d.Frame.Width)
+	case "south":
+		dy = amount.pixels(true, d.Frame.Height)
+	case "north":
+		dy = -amount.pixels(true, d.Frame.Height)
+	}
+	return y.Yabai("window", "--move", fmt.Sprintf("rel:%d:%d", int(dx), int(dy)))
+}
+
+// movePosition implements `move [absolute] position <x> [px|ppt] <y> [px|ppt]`
+// and `move [absolute] position center|mouse` for floating windows. Positions
+// are relative to the window's display, or to the bounds of every display
+// with absolute.
+func movePosition(y yabai.Client, target []string) error {
+	absolute := false
+	if len(target) > 0 && target[0] == "absolute" {
+		absolute = true
+		target = target[1:]
+	}
+	if len(target) < 2 || target[0] != "position" {
+		return ErrUnknownCommand
+	}
+	args := target[1:]
+
+	w, err := y.QueryActiveWindow()
+	if err != nil {
+		return err
+	}
+	if !w.IsFloating {
+		return ErrNotFloating
+	}
+	bounds, err := positionBounds(y, w, absolute)
+	if err != nil {
+		return err
+	}
+
+	var x, top float32
+	switch args[0] {
+	case "center":
+		if len(args) != 1 {
+			return ErrUnknownCommand
+		}
+		x = bounds.X + (bounds.Width-w.Frame.Width)/2
+		top = bounds.Y + (bounds.Height-w.Frame.Height)/2
+	case "mouse", "cursor", "pointer":
+		if len(args) != 1 {
+			return ErrUnknownCommand
+		}
+		x, top, err = mousePosition(y, w)
+		if err != nil {
+			return err
+		}
+	default:
+		amountX, rest := parseResizeAmount(args)
+		amountY, rest := parseResizeAmount(rest)
+		if len(rest) > 0 || !(amountX.hasPx || amountX.hasPpt) || !(amountY.hasPx || amountY.hasPpt) {
+			return ErrUnknownCommand
+		}
+		x = bounds.X + amountX.pixels(true, bounds.Width)
+		top = bounds.Y + amountY.pixels(true, bounds.Height)
+	}
+	return y.Yabai("window", "--move", fmt.Sprintf("abs:%d:%d", int(x), int(top)))
+}
+
+// positionBounds returns the frame of the window's display, or the frame
+// around every display.
+func positionBounds(y yabai.Client, w *yabai.Window, absolute bool) (*yabai.Frame, error) {
+	if !absolute {
+		d, err := getDisplay(y, w.Display)
+		if err != nil {
+			return nil, err
+		}
+		return d.Frame, nil
+	}
+	displays, err := y.QueryDisplays()
+	if err != nil {
+		return nil, err
+	}
+	if len(displays) == 0 {
+		return nil, ErrNoDisplay
+	}
+	left, top := float32(math.Inf(1)), float32(math.Inf(1))
+	right, bottom := float32(math.Inf(-1)), float32(math.Inf(-1))
+	for _, d := range displays {
+		left = min(left, d.Frame.X)
+		top = min(top, d.Frame.Y)
+		right = max(right, d.Frame.X+d.Frame.Width)
+		bottom = max(bottom, d.Frame.Y+d.Frame.Height)
+	}
+	return &yabai.Frame{X: left, Y: top, Width: right - left, Height: bottom - top}, nil
+}
+
+// mousePosition returns where to put a window so it is centered on the mouse
+// pointer while staying inside the display the pointer is on.
+func mousePosition(y yabai.Client, w *yabai.Window) (float32, float32, error) {
+	mx, my, err := mouseLocation()
+	if err != nil {
+		return 0, 0, err
+	}
+	displays, err := y.QueryDisplays()
+	if err != nil {
+		return 0, 0, err
+	}
+	var frame *yabai.Frame
+	for _, d := range displays {
+		f := d.Frame
+		if mx >= f.X && mx < f.X+f.Width && my >= f.Y && my < f.Y+f.Height {
+			frame = f
+			break
+		}
+	}
+	x := mx - w.Frame.Width/2
+	top := my - w.Frame.Height/2
+	if frame != nil {
+		x = max(frame.X, min(x, frame.X+frame.Width-w.Frame.Width))
+		top = max(frame.Y, min(top, frame.Y+frame.Height-w.Frame.Height))
+	}
+	return x, top, nil
+}
@@ -0,0 +1,60 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/abibby/yabai3/yabai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestMovePosition(t *testing.T) {
+	testCases := []struct {
+		name    string
+		command []string
+		frame   *yabai.Frame
+	}{
+		{
+			name:    "nudge",
+			command: []string{"move", "left", "20", "px"},
+			frame:   &yabai.Frame{X: 230, Y: 200, Width: 500, Height: 400},
+		},
+		{
+			name:    "nudge default",
+			command: []string{"move", "down"},
+			frame:   &yabai.Frame{X: 250, Y: 210, Width: 500, Height: 400},
+		},
+		{
+			name:    "position",
+			command: []string{"move", "window", "to", "position", "10", "px", "25", "ppt"},
+			frame:   &yabai.Frame{X: 10, Y: 200, Width: 500, Height: 400},
+		},
+		{
+			name:    "absolute center",
+			command: []string{"move", "absolute", "position", "center"},
+			frame:   &yabai.Frame{X: 750, Y: 200, Width: 500, Height: 400},
+		},
+		{
+			name:    "mouse",
+			command: []string{"move", "position", "mouse"},
+			frame:   &yabai.Frame{X: 1500, Y: 400, Width: 500, Height: 400},
+		},
+	}
+	mouseLocation = func() (float32, float32, error) { return 1900, 700, nil }
+	t.Cleanup(func() { mouseLocation = pointerLocation })
+
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newTwoDisplayFake()
+			ctx := newTestContext(f)
+			assert.NoError(t, Command(ctx, []string{"floating", "enable"}, noChangeMode, noRestart))
+			assert.NoError(t, Command(ctx, tc.command, noChangeMode, noRestart))
+			assert.Equal(t, tc.frame, window(t, f, "a").Frame)
+		})
+	}
+}
+
+func TestMovePosition_tiled(t *testing.T) {
+	ctx := newTestContext(newTwoDisplayFake())
+	err := Command(ctx, []string{"move", "position", "center"}, noChangeMode, noRestart)
+	assert.ErrorIs(t, err, ErrNotFloating)
+}
@@ -0,0 +1,296 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"slices"
+	"strings"
+	"sync"
+	"syscall"
+	"time"
+	"unicode"
+
+	"github.com/abibby/salusa/di"
+	"github.com/abibby/yabai3/yabai"
+	"github.com/mattn/go-shellwords"
+)
+
+// Process is a command started with exec that is still running. Source is
+// where it was started from, an exec or exec_always line in the config or an
+// exec command.
+type Process struct {
+	PID     int       `json:"pid"`
+	Command string    `json:"command"`
+	Source  string    `json:"source"`
+	Started time.Time `json:"started"`
+	Log     string    `json:"log"`
+}
+
+const (
+	SourceCommand    = "command"
+	SourceExec       = "exec"
+	SourceExecAlways = "exec_always"
+)
+
+// Processes starts exec commands without waiting for them and keeps track of
+// them until they exit. The output of every process is written to its own
+// file in logDir.
+type Processes struct {
+	mtx       *sync.Mutex
+	logDir    string
+	processes map[int]*Process
+	nextID    int
+}
+
+func NewProcesses(logDir string) *Processes {
+	return &Processes{
+		mtx:       &sync.Mutex{},
+		logDir:    logDir,
+		processes: map[int]*Process{},
+	}
+}
+
+func RegisterProcesses(ctx context.Context, p *Processes) {
+	di.RegisterSingleton(ctx, func() *Processes {
+		return p
+	})
+}
+
+// ExecOptions are the flags of an exec command.
+type ExecOptions struct {
+	NoStartupID bool
+}
+
+// parseExec splits the flags from the command of `exec [--no-startup-id]
+// <command>`.
+func parseExec(c []string) (string, *ExecOptions, error) {
+	opts := &ExecOptions{}
+	args := c[1:]
+	for len(args) > 1 {
+		switch args[0] {
+		case "--no-startup-id":
+			opts.NoStartupID = true
+		default:
+			return "", nil, fmt.Errorf("unknown exec option %s", args[0])
+		}
+		args = args[1:]
+	}
+	if len(args) != 1 || args[0] == "" {
+		return "", nil, ErrUnknownCommand
+	}
+	return args[0], opts, nil
+}
+
+// Start runs command with the shell and returns once it has started.
+// Applications that aren't on the PATH, like `exec Safari`, are launched with
+// open instead.
+func (p *Processes) Start(command string, opts *ExecOptions) (*Process, error) {
+	return p.start(command, opts, SourceCommand)
+}
+
+func (p *Processes) start(command string, opts *ExecOptions, source string) (*Process, error) {
+	p.mtx.Lock()
+	p.nextID++
+	id := p.nextID
+	p.mtx.Unlock()
+
+	cmd := launchCommand(command)
+	cmd.Env = execEnv(id, opts)
+	// a new process group so signals sent to yabai3 don't reach it
+	cmd.SysProcAttr = &syscall.SysProcAttr{Setpgid: true}
+
+	logFile, err := p.openLog(logName(command), id)
+	if err != nil {
+		return nil, err
+	}
+	cmd.Stdout = logFile
+	cmd.Stderr = logFile
+
+	err = cmd.Start()
+	if err != nil {
+		logFile.Close()
+		return nil, fmt.Errorf("exec %s: %w", command, err)
+	}
+
+	proc := &Process{
+		PID:     cmd.Process.Pid,
+		Command: command,
+		Source:  source,
+		Started: time.Now(),
+		Log:     logFile.Name(),
+	}
+	p.mtx.Lock()
+	p.processes[proc.PID] = proc
+	p.mtx.Unlock()
+
+	go p.wait(cmd, proc, logFile)
+	return proc, nil
+}
+
+// wait reaps the process and removes it from the list once it exits.
+func (p *Processes) wait(cmd *exec.Cmd, proc *Process, logFile *os.File) {
+	err := cmd.Wait()
+	if err != nil {
+		fmt.Fprintf(logFile, "yabai3: %s exited: %v\n", proc.Command, err)
+		log.Printf("exec %s: %v", proc.Command, err)
+	}
+	logFile.Close()
+
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	delete(p.processes, proc.PID)
+}
+
+// List returns the running processes, oldest first.
+func (p *Processes) List() []*Process {
+	p.mtx.Lock()
+	defer p.mtx.Unlock()
+	processes := make([]*Process, 0, len(p.processes))
+	for _, proc := range p.processes {
+		processes = append(processes, proc)
+	}
+	slices.SortFunc(processes, func(a, b *Process) int {
+		return a.Started.Compare(b.Started)
+	})
+	return processes
+}
+
+// maxLogs is how many log files are kept in the log directory, the oldest
+// are removed when a new process starts.
+const maxLogs = 100
+
+func (p *Processes) openLog(name string, id int) (*os.File, error) {
+	if p.logDir == "" {
+		return os.OpenFile(os.DevNull, os.O_WRONLY, 0)
+	}
+	err := os.MkdirAll(p.logDir, 0o755)
+	if err != nil {
+		return nil, err
+	}
+	err = p.pruneLogs(maxLogs - 1)
+	if err != nil {
+		log.Printf("exec: prune logs: %v", err)
+	}
+	file := path.Join(p.logDir, fmt.Sprintf("%s-%d-%d.log", name, os.Getpid(), id))
+	return os.Create(file)
+}
+
+// pruneLogs removes the oldest log files until at most keep are left.
+func (p *Processes) pruneLogs(keep int) error {
+	entries, err := os.ReadDir(p.logDir)
+	if err != nil {
+		return err
+	}
+	type logFile struct {
+		name    string
+		modTime time.Time
+	}
+	logs := []logFile{}
+	for _, e := range entries {
+		if e.IsDir() || path.Ext(e.Name()) != ".log" {
+			continue
+		}
+		info, err := e.Info()
+		if err != nil {
+			continue
+		}
+		logs = append(logs, logFile{e.Name(), info.ModTime()})
+	}
+	if len(logs) <= keep {
+		return nil
+	}
+	slices.SortFunc(logs, func(a, b logFile) int {
+		return a.modTime.Compare(b.modTime)
+	})
+	errs := []error{}
+	for _, l := range logs[:len(logs)-keep] {
+		err := os.Remove(path.Join(p.logDir, l.name))
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// logName names the log of command after the program it runs, leaving out
+// variable assignments like FOO=1 in front of it.
+func logName(command string) string {
+	args, err := shellwords.Parse(command)
+	if err != nil {
+		return "exec"
+	}
+	for _, arg := range args {
+		if strings.Contains(arg, "=") {
+			continue
+		}
+		name := strings.Map(func(r rune) rune {
+			if r == '.' || r == '-' || r == '_' || unicode.IsLetter(r) || unicode.IsDigit(r) {
+				return r
+			}
+			return '_'
+		}, path.Base(arg))
+		if name == "" || name == "." || name == ".." {
+			break
+		}
+		return name
+	}
+	return "exec"
+}
+
+func launchCommand(command string) *exec.Cmd {
+	args, err := shellwords.Parse(command)
+	if err == nil && len(args) > 0 && isApplication(args[0]) {
+		// -W keeps open running until the application quits so it can be
+		// supervised like any other process
+		openArgs := []string{"-a", args[0], "-n", "-W"}
+		if sock := os.Getenv("I3SOCK"); sock != "" {
+			// apps started with open are launched by launchd and don't
+			// inherit our environment
+			openArgs = append(openArgs, "--env", "I3SOCK="+sock)
+		}
+		openArgs = append(openArgs, "--args")
+		return exec.Command("open", append(openArgs, args[1:]...)...)
+	}
+	return exec.Command("sh", "-c", command)
+}
+
+// isApplication reports whether name is an application the shell can't run,
+// like Safari. Paths, variables and assignments are always left to the
+// shell.
+var isApplication = func(name string) bool {
+	if strings.ContainsAny(name, "/~$=") {
+		return false
+	}
+	if exec.Command("sh", "-c", "command -v \"$1\"", "sh", name).Run() == nil {
+		return false
+	}
+	// -R finds the application without launching it
+	return exec.Command("open", "-Ra", name).Run() == nil
+}
+
+// execEnv is the environment of a new process. It inherits I3SOCK from
+// yabai3, which the server exports when it starts. Unless --no-startup-id is
+// given DESKTOP_STARTUP_ID identifies the launch the way i3 does.
+func execEnv(id int, opts *ExecOptions) []string {
+	env := os.Environ()
+	if !opts.NoStartupID {
+		env = append(env, fmt.Sprintf("DESKTOP_STARTUP_ID=yabai3/%d-%d_TIME%d", os.Getpid(), id, time.Now().Unix()))
+	}
+	return env
+}
+
+func runExec(p *Processes) runner {
+	return func(y yabai.Client, c []string) error {
+		command, opts, err := parseExec(c)
+		if err != nil {
+			return err
+		}
+		_, err = p.Start(command, opts)
+		return err
+	}
+}
@@ -0,0 +1,128 @@
+package run
+
+import (
+	"fmt"
+	"os"
+	"path"
+	"path/filepath"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func waitForExit(t *testing.T, p *Processes) {
+	t.Helper()
+	assert.Eventually(t, func() bool {
+		return len(p.List()) == 0
+	}, 2*time.Second, 10*time.Millisecond)
+}
+
+func TestParseExec(t *testing.T) {
+	command, opts, err := parseExec([]string{"exec", "--no-startup-id", "kitty -e tmux"})
+	assert.NoError(t, err)
+	assert.Equal(t, "kitty -e tmux", command)
+	assert.True(t, opts.NoStartupID)
+
+	_, _, err = parseExec([]string{"exec", "--nope", "kitty"})
+	assert.EqualError(t, err, "unknown exec option --nope")
+
+	_, _, err = parseExec([]string{"exec"})
+	assert.ErrorIs(t, err, ErrUnknownCommand)
+}
+
+func TestProcesses(t *testing.T) {
+	p := NewProcesses(t.TempDir())
+
+	proc, err := p.Start(`echo "out $DESKTOP_STARTUP_ID"; echo err >&2`, &ExecOptions{NoStartupID: true})
+	if !assert.NoError(t, err) {
+		return
+	}
+	waitForExit(t, p)
+	b, err := os.ReadFile(proc.Log)
+	assert.NoError(t, err)
+	assert.Equal(t, "out \nerr\n", string(b))
+
+	proc, err = p.Start(`echo $DESKTOP_STARTUP_ID; exit 3`, &ExecOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	waitForExit(t, p)
+	b, err = os.ReadFile(proc.Log)
+	assert.NoError(t, err)
+	assert.Regexp(t, `^yabai3/\d+-2_TIME\d+\nyabai3: .* exited: exit status 3\n$`, string(b))
+}
+
+func TestProcesses_list(t *testing.T) {
+	p := NewProcesses("")
+	proc, err := p.Start("exec sleep 5", &ExecOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	if assert.Len(t, p.List(), 1) {
+		assert.Equal(t, proc.PID, p.List()[0].PID)
+		assert.Equal(t, "exec sleep 5", p.List()[0].Command)
+	}
+
+	assert.NoError(t, syscall.Kill(proc.PID, syscall.SIGTERM))
+	waitForExit(t, p)
+}
+
+func TestLaunchCommand(t *testing.T) {
+	old := isApplication
+	t.Cleanup(func() { isApplication = old })
+	isApplication = func(name string) bool {
+		return name == "Safari" || old(name)
+	}
+
+	testCases := []struct {
+		command string
+		args    []string
+	}{
+		{"Safari https://example.com", []string{"open", "-a", "Safari", "-n", "-W", "--args", "https://example.com"}},
+		{"~/bin/foo", []string{"sh", "-c", "~/bin/foo"}},
+		{"$HOME/bin/foo", []string{"sh", "-c", "$HOME/bin/foo"}},
+		{"FOO=1 cmd", []string{"sh", "-c", "FOO=1 cmd"}},
+		{"missing-command", []string{"sh", "-c", "missing-command"}},
+		{"echo hi", []string{"sh", "-c", "echo hi"}},
+	}
+	t.Setenv("I3SOCK", "")
+	for _, tc := range testCases {
+		t.Run(tc.command, func(t *testing.T) {
+			cmd := launchCommand(tc.command)
+			assert.Equal(t, tc.args, cmd.Args)
+		})
+	}
+}
+
+func TestLogName(t *testing.T) {
+	assert.Equal(t, "kitty", logName("kitty -e tmux"))
+	assert.Equal(t, "foo", logName("FOO=1 ~/bin/foo --bar"))
+	assert.Equal(t, "Google_Chrome", logName(`"Google Chrome" --incognito`))
+	assert.Equal(t, "exec", logName(`"unterminated`))
+}
+
+func TestProcesses_pruneLogs(t *testing.T) {
+	dir := t.TempDir()
+	old := time.Now().Add(-time.Hour)
+	for i := range maxLogs {
+		file := path.Join(dir, fmt.Sprintf("old-%d.log", i))
+		assert.NoError(t, os.WriteFile(file, nil, 0o644))
+		assert.NoError(t, os.Chtimes(file, old, old.Add(time.Duration(i)*time.Second)))
+	}
+
+	p := NewProcesses(dir)
+	proc, err := p.Start("true", &ExecOptions{})
+	if !assert.NoError(t, err) {
+		return
+	}
+	waitForExit(t, p)
+	assert.Regexp(t, `/true-\d+-1\.log$`, proc.Log)
+
+	logs, err := filepath.Glob(path.Join(dir, "*.log"))
+	assert.NoError(t, err)
+	assert.Len(t, logs, maxLogs)
+	assert.NoFileExists(t, path.Join(dir, "old-0.log"))
+	assert.FileExists(t, path.Join(dir, "old-1.log"))
+}
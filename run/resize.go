@@ -0,0 +1,189 @@
+package run
+
+import (
+	"fmt"
+	"strconv"
+
+	"github.com/abibby/yabai3/yabai"
+)
+
+// resizeEdges are the window edges each resize direction moves, with the
+// edge to fall back to when the window has no neighbour on the first one.
+var resizeEdges = map[string][]string{
+	"up":     {"top"},
+	"down":   {"bottom"},
+	"left":   {"left"},
+	"right":  {"right"},
+	"width":  {"right", "left"},
+	"height": {"bottom", "top"},
+}
+
+// resizeAmount is an amount in px, ppt or both as in `10 px or 10 ppt`.
+type resizeAmount struct {
+	px  float32
+	ppt float32
+
+	hasPx  bool
+	hasPpt bool
+}
+
+// parseResizeAmount parses `<n> [px|ppt] [or <n> ppt]`. Without a unit the
+// amount is in px.
+func parseResizeAmount(args []string) (*resizeAmount, []string) {
+	a := &resizeAmount{}
+	for len(args) > 0 {
+		n, err := strconv.ParseFloat(args[0], 32)
+		if err != nil {
+			break
+		}
+		args = args[1:]
+		unit := "px"
+		if len(args) > 0 && (args[0] == "px" || args[0] == "ppt") {
+			unit = args[0]
+			args = args[1:]
+		}
+		if unit == "px" {
+			a.px, a.hasPx = float32(n), true
+		} else {
+			a.ppt, a.hasPpt = float32(n), true
+		}
+		if len(args) == 0 || args[0] != "or" {
+			break
+		}
+		args = args[1:]
+	}
+	return a, args
+}
+
+// pixels returns the amount in px for a window. Tiled windows use ppt when it
+// is given, floating windows only use it when there is no px amount, like
+// i3. ppt is relative to size, the width or height of the display.
+func (a *resizeAmount) pixels(floating bool, size float32) float32 {
+	if a.hasPpt && (!floating || !a.hasPx) {
+		return a.ppt * size / 100
+	}
+	return a.px
+}
+
+// runResize implements `resize grow|shrink <direction> [<n> px [or <n> ppt]]`
+// and `resize set [width] <w> [px|ppt] [height] <h> [px|ppt]`.
+func runResize(y yabai.Client, c []string) error {
+	if len(c) < 2 {
+		return ErrUnknownCommand
+	}
+	if c[1] == "set" {
+		return resizeSet(y, c[2:])
+	}
+	if len(c) < 3 || (c[1] != "grow" && c[1] != "shrink") {
+		return ErrUnknownCommand
+	}
+	edges, ok := resizeEdges[c[2]]
+	if !ok {
+		return ErrUnknownCommand
+	}
+	amount := &resizeAmount{px: 10, ppt: 10, hasPx: true, hasPpt: true}
+	if len(c) > 3 {
+		var rest []string
+		amount, rest = parseResizeAmount(c[3:])
+		if len(rest) > 0 || (!amount.hasPx && !amount.hasPpt) {
+			return ErrUnknownCommand
+		}
+	}
+
+	w, d, err := activeWindowDisplay(y)
+	if err != nil {
+		return err
+	}
+	size := d.Frame.Width
+	if c[2] == "up" || c[2] == "down" || c[2] == "height" {
+		size = d.Frame.Height
+	}
+	n := amount.pixels(w.IsFloating, size)
+	if c[1] == "shrink" {
+		n = -n
+	}
+	return resizeEdge(y, edges, n)
+}
+
+// resizeEdge grows the window by n px on the first of edges that can be
+// moved. Tiled windows can only be resized on edges shared with another
+// window, the opposite edge is used when the window is on the side of its
+// part of the bsp tree.
+func resizeEdge(y yabai.Client, edges []string, n float32) error {
+	var err error
+	for _, edge := range edges {
+		dx, dy := float32(0), float32(0)
+		switch edge {
+		case "right":
+			dx = n
+		case "left":
+			dx = -n
+		case "bottom":
+			dy = n
+		case "top":
+			dy = -n
+		}
+		err = y.Yabai("window", "--resize", fmt.Sprintf("%s:%d:%d", edge, int(dx), int(dy)))
+		if err == nil {
+			return nil
+		}
+	}
+	return err
+}
+
+// resizeSet resizes the window to an absolute size. A size of 0 or a missing
+// one is left unchanged.
+func resizeSet(y yabai.Client, args []string) error {
+	amounts := [2]*resizeAmount{{}, {}}
+	for i, name := range []string{"width", "height"} {
+		if len(args) > 0 && args[0] == name {
+			args = args[1:]
+		}
+		if len(args) == 0 {
+			break
+		}
+		amounts[i], args = parseResizeAmount(args)
+	}
+	if len(args) > 0 {
+		return ErrUnknownCommand
+	}
+
+	w, d, err := activeWindowDisplay(y)
+	if err != nil {
+		return err
+	}
+	width := amounts[0].pixels(w.IsFloating, d.Frame.Width)
+	height := amounts[1].pixels(w.IsFloating, d.Frame.Height)
+	if width <= 0 {
+		width = w.Frame.Width
+	}
+	if height <= 0 {
+		height = w.Frame.Height
+	}
+
+	if w.IsFloating {
+		return y.Yabai("window", "--resize", fmt.Sprintf("abs:%d:%d", int(width), int(height)))
+	}
+	if width != w.Frame.Width {
+		err = resizeEdge(y, resizeEdges["width"], width-w.Frame.Width)
+		if err != nil {
+			return err
+		}
+	}
+	if height != w.Frame.Height {
+		return resizeEdge(y, resizeEdges["height"], height-w.Frame.Height)
+	}
+	return nil
+}
+
+func activeWindowDisplay(y yabai.Client) (*yabai.Window, *yabai.Display, error) {
+	w, err := y.QueryActiveWindow()
+	if err != nil {
+		return nil, nil, err
+	}
+	d, err := getDisplay(y, w.Display)
+	if err != nil {
+		return nil, nil, err
+	}
+	return w, d, nil
+}
@@ -0,0 +1,84 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/abibby/yabai3/yabai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseResizeAmount(t *testing.T) {
+	a, rest := parseResizeAmount([]string{"20", "px", "or", "5", "ppt"})
+	assert.Equal(t, &resizeAmount{px: 20, ppt: 5, hasPx: true, hasPpt: true}, a)
+	assert.Empty(t, rest)
+
+	a, rest = parseResizeAmount([]string{"15", "height", "10"})
+	assert.Equal(t, &resizeAmount{px: 15, hasPx: true}, a)
+	assert.Equal(t, []string{"height", "10"}, rest)
+}
+
+func TestResize(t *testing.T) {
+	testCases := []struct {
+		name     string
+		commands [][]string
+		a, b     *yabai.Frame
+	}{
+		{
+			name:     "grow width in ppt",
+			commands: [][]string{{"resize", "grow", "width", "10", "px", "or", "5", "ppt"}},
+			a:        &yabai.Frame{Width: 550, Height: 800},
+			b:        &yabai.Frame{X: 550, Width: 450, Height: 800},
+		},
+		{
+			name:     "width falls back to the left edge",
+			commands: [][]string{{"focus", "right"}, {"resize", "shrink", "width", "20", "px"}},
+			a:        &yabai.Frame{Width: 520, Height: 800},
+			b:        &yabai.Frame{X: 520, Width: 480, Height: 800},
+		},
+		{
+			name:     "grow left",
+			commands: [][]string{{"focus", "right"}, {"resize", "grow", "left", "50"}},
+			a:        &yabai.Frame{Width: 450, Height: 800},
+			b:        &yabai.Frame{X: 450, Width: 550, Height: 800},
+		},
+		{
+			name:     "set tiled",
+			commands: [][]string{{"resize", "set", "width", "25", "ppt"}},
+			a:        &yabai.Frame{Width: 250, Height: 800},
+			b:        &yabai.Frame{X: 250, Width: 750, Height: 800},
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			f := newTwoDisplayFake()
+			ctx := newTestContext(f)
+			for _, c := range tc.commands {
+				assert.NoError(t, Command(ctx, c, noChangeMode, noRestart))
+			}
+			assert.Equal(t, tc.a, window(t, f, "a").Frame)
+			assert.Equal(t, tc.b, window(t, f, "b").Frame)
+		})
+	}
+}
+
+func TestResize_floating(t *testing.T) {
+	f := newTwoDisplayFake()
+	ctx := newTestContext(f)
+	run := func(c ...string) {
+		t.Helper()
+		assert.NoError(t, Command(ctx, c, noChangeMode, noRestart))
+	}
+
+	run("floating", "enable")
+	run("resize", "set", "640", "480")
+	a := window(t, f, "a")
+	assert.Equal(t, float32(640), a.Frame.Width)
+	assert.Equal(t, float32(480), a.Frame.Height)
+
+	// floating windows use px when there is a px amount
+	run("resize", "grow", "height", "10", "px", "or", "50", "ppt")
+	assert.Equal(t, float32(490), window(t, f, "a").Frame.Height)
+
+	err := Command(ctx, []string{"resize", "grow", "sideways", "10"}, noChangeMode, noRestart)
+	assert.ErrorIs(t, err, ErrUnknownCommand)
+}
@@ -0,0 +1,149 @@
+package run
+
+import (
+	"errors"
+	"fmt"
+	"slices"
+
+	"github.com/abibby/yabai3/i3parser"
+	"github.com/abibby/yabai3/yabai"
+)
+
+const ruleLabelPrefix = "yabai3_for_window_"
+
+// ruleProperties maps criteria to the yabai rule property that matches the
+// same window field.
+var ruleProperties = map[string]string{
+	"class":       "app",
+	"instance":    "app",
+	"title":       "title",
+	"window_role": "role",
+	"window_type": "subrole",
+}
+
+// dynamicWorkspaces are workspace names that depend on which workspace is
+// focused and can't be used as a rule's space.
+var dynamicWorkspaces = []string{"next", "prev", "next_on_output", "prev_on_output", "current", "back_and_forth", "number"}
+
+// StaticRule returns the properties of a yabai rule with the same effect as a
+// for_window rule, so it applies before the window is first drawn. ok is false
+// if the criteria or commands can't be expressed as a yabai rule, those rules
+// are run when yabai signals that a window was created. A static rule without
+// anything to change returns nil properties.
+func StaticRule(conditions *i3parser.Conditions, commands *i3parser.Commands) (properties []string, ok bool) {
+	matches, ok := ruleMatches(conditions)
+	if !ok {
+		return nil, false
+	}
+
+	effects := []string{}
+	for _, chain := range commands.Chains {
+		if chain.Conditions != nil {
+			return nil, false
+		}
+		for _, c := range chain.Commands {
+			effect, ok := ruleEffect(c.Args())
+			if !ok {
+				return nil, false
+			}
+			if effect != "" {
+				effects = append(effects, effect)
+			}
+		}
+	}
+	if len(effects) == 0 {
+		return nil, true
+	}
+	return append(matches, effects...), true
+}
+
+// ruleMatches returns the rule properties that match the same windows as
+// conditions.
+func ruleMatches(conditions *i3parser.Conditions) ([]string, bool) {
+	matches := []string{}
+	used := map[string]bool{}
+	for _, c := range conditions.Conditions {
+		property, ok := ruleProperties[c.Type.Value]
+		if !ok || c.Value == nil || c.Value.Value == focusedValue || used[property] {
+			return nil, false
+		}
+		used[property] = true
+
+		value := c.Value.Value
+		if c.Type.Value == "window_type" {
+			value = subroleRegexp(value)
+		}
+		matches = append(matches, property+"="+value)
+	}
+	return matches, true
+}
+
+func subroleRegexp(windowType string) string {
+	subroles, ok := windowTypes[windowType]
+	if !ok {
+		subroles = []string{windowType}
+	}
+	re := "^("
+	for i, s := range subroles {
+		if i > 0 {
+			re += "|"
+		}
+		re += s
+	}
+	return re + ")$"
+}
+
+// ruleEffect returns the rule property for a command, an empty property for
+// commands that don't do anything under yabai and false for commands that
+// need to run against the window.
+func ruleEffect(c []string) (string, bool) {
+	switch c[0] {
+	case "floating":
+		if len(c) != 2 {
+			return "", false
+		}
+		switch c[1] {
+		case "enable":
+			return "manage=off", true
+		case "disable":
+			return "manage=on", true
+		}
+	case "border":
+		return "", true
+	case "move":
+		target := moveTarget(c)
+		if len(target) == 2 && target[0] == "workspace" && !slices.Contains(dynamicWorkspaces, target[1]) {
+			return "space=" + target[1], true
+		}
+	}
+	return "", false
+}
+
+// SetRules replaces the rules added by a previous call with rules. Nil rules
+// are skipped.
+func SetRules(y yabai.Client, rules [][]string) error {
+	// remove rules until one is missing, the previous config may have had
+	// more of them
+	for i := 0; ; i++ {
+		err := y.Yabai("rule", "--remove", fmt.Sprintf("%s%d", ruleLabelPrefix, i))
+		if err != nil {
+			break
+		}
+	}
+
+	errs := []error{}
+	i := 0
+	for _, rule := range rules {
+		if rule == nil {
+			continue
+		}
+		args := append([]string{"rule", "--add", fmt.Sprintf("label=%s%d", ruleLabelPrefix, i)}, rule...)
+		err := y.Yabai(args...)
+		if err != nil {
+			errs = append(errs, err)
+			continue
+		}
+		i++
+	}
+	return errors.Join(errs...)
+}
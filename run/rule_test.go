@@ -0,0 +1,135 @@
+package run
+
+import (
+	"testing"
+
+	"github.com/abibby/yabai3/i3parser"
+	"github.com/abibby/yabai3/parser"
+	"github.com/abibby/yabai3/yabai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStaticRule(t *testing.T) {
+	testCases := []struct {
+		name       string
+		conditions string
+		commands   string
+		properties []string
+		static     bool
+	}{
+		{
+			name:       "floating",
+			conditions: `[class="Firefox" title="Preferences"]`,
+			commands:   "floating enable",
+			properties: []string{"app=Firefox", "title=Preferences", "manage=off"},
+			static:     true,
+		},
+		{
+			name:       "move to workspace",
+			conditions: `[window_type=dialog]`,
+			commands:   "move container to workspace 3, border none",
+			properties: []string{"subrole=^(AXDialog|AXSystemDialog)$", "space=3"},
+			static:     true,
+		},
+		{
+			name:       "nothing to change",
+			conditions: `[class="Firefox"]`,
+			commands:   "border pixel 1",
+			properties: nil,
+			static:     true,
+		},
+		{
+			name:       "focused",
+			conditions: `[workspace=__focused__]`,
+			commands:   "floating enable",
+			static:     false,
+		},
+		{
+			name:       "command",
+			conditions: `[class="Firefox"]`,
+			commands:   "fullscreen toggle",
+			static:     false,
+		},
+	}
+	for _, tc := range testCases {
+		t.Run(tc.name, func(t *testing.T) {
+			commands, err := i3parser.ParseCommandString(tc.conditions + " " + tc.commands)
+			if !assert.NoError(t, err) {
+				return
+			}
+			chain := commands.Chains[0]
+			conditions := chain.Conditions
+			chain.Conditions = nil
+
+			properties, static := StaticRule(conditions, commands)
+			assert.Equal(t, tc.static, static)
+			assert.Equal(t, tc.properties, properties)
+		})
+	}
+}
+
+func TestSetRules(t *testing.T) {
+	f := yabai.NewFake()
+	assert.NoError(t, SetRules(f, [][]string{{"app=a", "manage=off"}, nil, {"app=b", "space=2"}}))
+	assert.Equal(t, map[string][]string{
+		"yabai3_for_window_0": {"label=yabai3_for_window_0", "app=a", "manage=off"},
+		"yabai3_for_window_1": {"label=yabai3_for_window_1", "app=b", "space=2"},
+	}, f.Rules())
+
+	assert.NoError(t, SetRules(f, [][]string{{"app=c", "manage=off"}}))
+	assert.Equal(t, map[string][]string{
+		"yabai3_for_window_0": {"label=yabai3_for_window_0", "app=c", "manage=off"},
+	}, f.Rules())
+}
+
+func parseAssign(t *testing.T, src string) *i3parser.Assign {
+	d, err := i3parser.ParseDocument(nil, parser.NewReader("config", []byte(src)))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return d.(*i3parser.Document).Children()[0].(*i3parser.Assign)
+}
+
+func TestAssignRule(t *testing.T) {
+	properties, ok := AssignRule(parseAssign(t, `assign [class="Mail"] mail`))
+	assert.True(t, ok)
+	assert.Equal(t, []string{"app=Mail", "space=mail"}, properties)
+
+	_, ok = AssignRule(parseAssign(t, `assign [class="Mail"] number 3: mail`))
+	assert.False(t, ok)
+
+	_, ok = AssignRule(parseAssign(t, `assign [class="Mail"] output right`))
+	assert.False(t, ok)
+}
+
+func TestAssign(t *testing.T) {
+	f := newTwoDisplayFake()
+	windows, err := f.QueryWindows()
+	assert.NoError(t, err)
+	a := windows[0]
+
+	assert.NoError(t, Assign(f, a, parseAssign(t, `assign [class="a"] output right`)))
+	assert.Equal(t, 2, windowSpace(t, f, "a"))
+
+	windows, err = f.QueryWindows()
+	assert.NoError(t, err)
+	assert.NoError(t, Assign(f, windows[0], parseAssign(t, `assign [class="a"] workspace 1`)))
+	assert.Equal(t, 1, windowSpace(t, f, "a"))
+	// assigning to the space the window is already on does nothing
+	windows, err = f.QueryWindows()
+	assert.NoError(t, err)
+	assert.NoError(t, Assign(f, windows[0], parseAssign(t, `assign [class="a"] workspace 1`)))
+}
+
+func TestAssign_number(t *testing.T) {
+	f := newTwoDisplayFake()
+	f.AddSpace(1)
+	assert.NoError(t, f.Yabai("space", "2", "--label", "3: mail"))
+	windows, err := f.QueryWindows()
+	assert.NoError(t, err)
+
+	// the space named "3: mail" is workspace number 3, not the one with the
+	// index 3
+	assert.NoError(t, Assign(f, windows[0], parseAssign(t, `assign [class="a"] number 3`)))
+	assert.Equal(t, 2, windowSpace(t, f, "a"))
+}
@@ -0,0 +1,233 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"os"
+	"path"
+	"slices"
+	"strconv"
+	"sync"
+
+	"github.com/abibby/salusa/di"
+	"github.com/abibby/yabai3/yabai"
+)
+
+// Scratchpad is the ordered list of windows hidden with `move scratchpad`,
+// least recently shown first. It is saved to a file after every change so
+// hidden windows can still be shown after yabai3 restarts.
+type Scratchpad struct {
+	mtx     *sync.Mutex
+	path    string
+	windows []int
+}
+
+// NewScratchpad loads the scratchpad saved at path. An empty path keeps the
+// scratchpad in memory.
+func NewScratchpad(path string) (*Scratchpad, error) {
+	s := &Scratchpad{
+		mtx:     &sync.Mutex{},
+		path:    path,
+		windows: []int{},
+	}
+	if path == "" {
+		return s, nil
+	}
+	b, err := os.ReadFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		return s, nil
+	} else if err != nil {
+		return nil, err
+	}
+	err = json.Unmarshal(b, &s.windows)
+	if err != nil {
+		return nil, err
+	}
+	return s, nil
+}
+
+func RegisterScratchpad(ctx context.Context, s *Scratchpad) {
+	di.RegisterSingleton(ctx, func() *Scratchpad {
+		return s
+	})
+}
+
+// Windows returns the ids of the scratchpad windows in the order they are
+// shown.
+func (s *Scratchpad) Windows() []int {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return slices.Clone(s.windows)
+}
+
+func (s *Scratchpad) has(id int) bool {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return slices.Contains(s.windows, id)
+}
+
+// push moves a window to the end of the scratchpad, adding it if it isn't
+// already there.
+func (s *Scratchpad) push(id int) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.windows = slices.DeleteFunc(s.windows, func(w int) bool { return w == id })
+	s.windows = append(s.windows, id)
+	return s.save()
+}
+
+// prune removes windows that have been closed.
+func (s *Scratchpad) prune(windows map[int]*yabai.Window) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	n := len(s.windows)
+	s.windows = slices.DeleteFunc(s.windows, func(id int) bool {
+		_, ok := windows[id]
+		return !ok
+	})
+	if len(s.windows) == n {
+		return nil
+	}
+	return s.save()
+}
+
+func (s *Scratchpad) save() error {
+	if s.path == "" {
+		return nil
+	}
+	b, err := json.Marshal(s.windows)
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(path.Dir(s.path), 0o755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, b, 0o644)
+}
+
+// moveScratchpad floats and minimizes the window and adds it to the end of
+// the scratchpad.
+func moveScratchpad(y yabai.Client, s *Scratchpad) error {
+	w, err := y.QueryActiveWindow()
+	if err != nil {
+		return err
+	}
+	id := strconv.Itoa(w.ID)
+	if !w.IsFloating {
+		err = y.Yabai("window", id, "--toggle", "float")
+		if err != nil {
+			return err
+		}
+	}
+	err = y.Yabai("window", id, "--minimize")
+	if err != nil {
+		return err
+	}
+	return s.push(w.ID)
+}
+
+// runScratchpad implements `scratchpad show`. The focused scratchpad window
+// is hidden again, a scratchpad window on the current space is focused and
+// otherwise the least recently shown scratchpad window is brought to the
+// current space. With criteria the matching scratchpad windows are toggled.
+func runScratchpad(s *session) runner {
+	return func(y yabai.Client, c []string) error {
+		if len(c) != 2 || c[1] != "show" {
+			return ErrUnknownCommand
+		}
+		windows, err := y.QueryWindows()
+		if err != nil {
+			return err
+		}
+		byID := map[int]*yabai.Window{}
+		for _, w := range windows {
+			byID[w.ID] = w
+		}
+		err = s.scratchpad.prune(byID)
+		if err != nil {
+			return err
+		}
+		// the scratchpad is shown on the focused space even for criteria
+		space, err := focusedClient(y).QueryActiveSpace()
+		if err != nil {
+			return err
+		}
+
+		if target, ok := y.(*windowClient); ok {
+			w, ok := byID[target.window.ID]
+			if !ok || !s.scratchpad.has(w.ID) {
+				return nil
+			}
+			if w.HasFocus {
+				return hideScratchpad(y, s.scratchpad, w)
+			}
+			if onSpace(w, space) {
+				return y.Yabai("window", "--focus", strconv.Itoa(w.ID))
+			}
+			return showScratchpad(y, s, w, space)
+		}
+
+		ids := s.scratchpad.Windows()
+		for _, id := range ids {
+			if w := byID[id]; w.HasFocus {
+				return hideScratchpad(y, s.scratchpad, w)
+			}
+		}
+		for _, id := range ids {
+			if w := byID[id]; onSpace(w, space) {
+				return y.Yabai("window", "--focus", strconv.Itoa(w.ID))
+			}
+		}
+		if len(ids) == 0 {
+			return nil
+		}
+		return showScratchpad(y, s, byID[ids[0]], space)
+	}
+}
+
+func onSpace(w *yabai.Window, space *yabai.Space) bool {
+	return w.Space == space.Index && !w.IsMinimized
+}
+
+func hideScratchpad(y yabai.Client, s *Scratchpad, w *yabai.Window) error {
+	err := y.Yabai("window", strconv.Itoa(w.ID), "--minimize")
+	if err != nil {
+		return err
+	}
+	return s.push(w.ID)
+}
+
+// showScratchpad brings a scratchpad window to the space and centers it as a
+// floating window.
+func showScratchpad(y yabai.Client, s *session, w *yabai.Window, space *yabai.Space) error {
+	id := strconv.Itoa(w.ID)
+	if w.IsMinimized {
+		err := y.Yabai("window", id, "--deminimize", id)
+		if err != nil {
+			return err
+		}
+	}
+	if w.Space != space.Index {
+		err := y.Yabai("window", id, "--space", strconv.Itoa(space.Index))
+		if err != nil {
+			return err
+		}
+	}
+	if !w.IsFloating {
+		err := y.Yabai("window", id, "--toggle", "float")
+		if err != nil {
+			return err
+		}
+	}
+	err := y.Yabai("window", id, "--focus")
+	if err != nil {
+		return err
+	}
+	err = centerFloating(y, s.options, w.ID, space.DisplayIndex)
+	if err != nil {
+		return err
+	}
+	return s.scratchpad.push(w.ID)
+}
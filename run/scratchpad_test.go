@@ -0,0 +1,92 @@
+package run
+
+import (
+	"path"
+	"testing"
+
+	"github.com/abibby/salusa/di"
+	"github.com/abibby/yabai3/i3parser"
+	"github.com/abibby/yabai3/yabai"
+	"github.com/stretchr/testify/assert"
+)
+
+func window(t *testing.T, f *yabai.Fake, app string) *yabai.Window {
+	windows, err := f.QueryWindows()
+	assert.NoError(t, err)
+	for _, w := range windows {
+		if w.App == app {
+			return w
+		}
+	}
+	t.Fatalf("no window %s", app)
+	return nil
+}
+
+func TestScratchpad(t *testing.T) {
+	f := newTwoDisplayFake()
+	ctx := newTestContext(f)
+	run := func(c ...string) {
+		t.Helper()
+		assert.NoError(t, Command(ctx, c, noChangeMode, noRestart))
+	}
+
+	run("move", "scratchpad")
+	run("move", "window", "to", "scratchpad")
+	assert.True(t, window(t, f, "a").IsMinimized)
+	assert.True(t, window(t, f, "b").IsMinimized)
+
+	run("focus", "right")
+	run("scratchpad", "show")
+	a := window(t, f, "a")
+	assert.False(t, a.IsMinimized)
+	assert.True(t, a.IsFloating)
+	assert.Equal(t, 2, a.Space)
+	assert.Equal(t, &yabai.Frame{X: 1250, Y: 200, Width: 500, Height: 400}, a.Frame)
+	assert.Equal(t, "a", activeApp(t, f))
+
+	// showing it again hides it and the next press shows the next window
+	run("scratchpad", "show")
+	assert.True(t, window(t, f, "a").IsMinimized)
+	run("scratchpad", "show")
+	assert.Equal(t, "b", activeApp(t, f))
+	assert.True(t, window(t, f, "a").IsMinimized)
+
+	scratchpad, err := di.Resolve[*Scratchpad](ctx)
+	assert.NoError(t, err)
+	b := window(t, f, "b")
+	assert.Equal(t, []int{a.ID, b.ID}, scratchpad.Windows())
+}
+
+func TestScratchpad_criteria(t *testing.T) {
+	f := newTwoDisplayFake()
+	ctx := newTestContext(f)
+	assert.NoError(t, Command(ctx, []string{"move", "scratchpad"}, noChangeMode, noRestart))
+	assert.NoError(t, Command(ctx, []string{"move", "scratchpad"}, noChangeMode, noRestart))
+
+	commands, err := i3parser.ParseCommandString(`[app="b"] scratchpad show`)
+	if !assert.NoError(t, err) {
+		return
+	}
+	for _, err := range Commands(ctx, commands, noChangeMode, noRestart) {
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, "b", activeApp(t, f))
+	assert.True(t, window(t, f, "a").IsMinimized)
+}
+
+func TestScratchpad_persisted(t *testing.T) {
+	file := path.Join(t.TempDir(), "state", "scratchpad.json")
+	s, err := NewScratchpad(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, s.push(3))
+	assert.NoError(t, s.push(4))
+	assert.NoError(t, s.push(3))
+
+	s, err = NewScratchpad(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.Equal(t, []int{4, 3}, s.Windows())
+}
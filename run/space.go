@@ -3,8 +3,7 @@ package run
 import (
 	"fmt"
 	"slices"
-	"sync"
-	"time"
+	"strconv"
 
 	"github.com/abibby/yabai3/yabai"
 )
@@ -13,34 +12,10 @@ var (
 	ErrNoDisplay = fmt.Errorf("no display")
 )
 
-func cache[T any](timeout time.Duration, fetch func() (T, error)) func() (T, error) {
-	var value T
-	var hasValue = false
-	var mtx = &sync.Mutex{}
-
-	return func() (T, error) {
-		mtx.Lock()
-		defer mtx.Unlock()
-
-		if !hasValue {
-			v, err := fetch()
-			if err != nil {
-				var zero T
-				return zero, err
-			}
-			value = v
-			hasValue = true
-		}
-		return value, nil
-	}
-}
-
-var getDisplays = cache(time.Second, yabai.QueryDisplays)
-
 // var configuredSpaces = map[int]struct{}{}
 
-func getDisplay(index int) (*yabai.Display, error) {
-	displays, err := getDisplays()
+func getDisplay(y yabai.Client, index int) (*yabai.Display, error) {
+	displays, err := y.QueryDisplays()
 	if err != nil {
 		return nil, err
 	}
@@ -53,32 +28,48 @@ func getDisplay(index int) (*yabai.Display, error) {
 	return nil, ErrNoDisplay
 }
 
-func getDisplayFrom(displayNames []string) (*yabai.Display, error) {
-	displays, err := getDisplays()
+// getDisplayFrom returns the display for the first of displayNames that is
+// connected. Displays are named left, center or right by position, primary
+// for the display with the menu bar, by index or by their OutputName.
+func getDisplayFrom(y yabai.Client, displayNames []string) (*yabai.Display, error) {
+	displays, err := y.QueryDisplays()
 	if err != nil {
 		return nil, err
 	}
+	// yabai can report no displays while they are being reconfigured
+	if len(displays) == 0 {
+		return nil, ErrNoDisplay
+	}
 
-	slices.SortFunc(displays, func(a, b *yabai.Display) int {
+	byPosition := slices.Clone(displays)
+	slices.SortFunc(byPosition, func(a, b *yabai.Display) int {
 		return int(a.Frame.X) - int(b.Frame.X)
 	})
 
 	for _, name := range displayNames {
 		switch name {
 		case "left":
-			return displays[0], nil
+			return byPosition[0], nil
 		case "center":
-			return displays[len(displays)/2], nil
+			return byPosition[len(byPosition)/2], nil
 		case "right":
-			return displays[len(displays)-1], nil
+			return byPosition[len(byPosition)-1], nil
+		case "primary":
+			name = "1"
+		}
+		index, err := strconv.Atoi(name)
+		for _, d := range displays {
+			if (err == nil && d.Index == index) || OutputName(d) == name {
+				return d, nil
+			}
 		}
 	}
 
 	return nil, ErrNoDisplay
 }
 
-func LabelSpace(spaceCache map[int]struct{}, displayNames []string, name string) error {
-	d, err := getDisplayFrom(displayNames)
+func LabelSpace(y yabai.Client, spaceCache map[int]struct{}, displayNames []string, name string) error {
+	d, err := getDisplayFrom(y, displayNames)
 	if err != nil {
 		return err
 	}
@@ -87,7 +78,7 @@ func LabelSpace(spaceCache map[int]struct{}, displayNames []string, name string)
 			continue
 		}
 		spaceCache[spaceIndex] = struct{}{}
-		return yabai.Yabai("space", fmt.Sprint(spaceIndex), "--label", name)
+		return y.Yabai("space", fmt.Sprint(spaceIndex), "--label", name)
 	}
 	return nil
 }
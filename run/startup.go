@@ -0,0 +1,178 @@
+package run
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"os/exec"
+	"path"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/abibby/salusa/di"
+	"github.com/abibby/yabai3/config"
+)
+
+// StartupCommand is an exec or exec_always line from the config that was
+// run.
+type StartupCommand struct {
+	Command string    `json:"command"`
+	Source  string    `json:"source"`
+	PID     int       `json:"pid,omitempty"`
+	Started time.Time `json:"started"`
+	Error   string    `json:"error,omitempty"`
+}
+
+// StartupStatus is what Startup has run so far.
+type StartupStatus struct {
+	Session  string            `json:"session"`
+	ExecRan  bool              `json:"exec_ran"`
+	Commands []*StartupCommand `json:"commands"`
+}
+
+// Startup runs the exec and exec_always commands of the config. exec
+// commands run once per login session, the session they ran in is saved in
+// path so restarting yabai3 doesn't launch them again. exec_always commands
+// run every time the config is loaded.
+type Startup struct {
+	mtx       *sync.Mutex
+	path      string
+	processes *Processes
+	session   string
+	execRan   bool
+	commands  []*StartupCommand
+}
+
+func NewStartup(path string, processes *Processes) *Startup {
+	return &Startup{
+		mtx:       &sync.Mutex{},
+		path:      path,
+		processes: processes,
+		commands:  []*StartupCommand{},
+	}
+}
+
+func RegisterStartup(ctx context.Context, s *Startup) {
+	di.RegisterSingleton(ctx, func() *Startup {
+		return s
+	})
+}
+
+type startupState struct {
+	Session string `json:"session"`
+}
+
+// sessionID identifies the login session. macOS gives the processes of every
+// login session the same SECURITYSESSIONID, those are reused after a reboot
+// so the boot time is part of it as well.
+var sessionID = func() (string, error) {
+	b, err := exec.Command("sysctl", "-n", "kern.boottime").Output()
+	if err != nil {
+		return "", fmt.Errorf("boot time: %w", err)
+	}
+	return os.Getenv("SECURITYSESSIONID") + " " + strings.TrimSpace(string(b)), nil
+}
+
+// Exec runs commands unless they have already run in this login session. If
+// the session can't be identified they are run anyway.
+func (s *Startup) Exec(commands []*config.Exec) error {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+
+	session, err := sessionID()
+	if err != nil {
+		log.Printf("exec: could not identify the login session: %v", err)
+	}
+	s.session = session
+	if s.execRan {
+		return nil
+	}
+	if session != "" && s.savedSession() == session {
+		log.Print("exec: already ran in this login session")
+		s.execRan = true
+		return nil
+	}
+
+	s.run(commands, SourceExec)
+	s.execRan = true
+	if session == "" {
+		return nil
+	}
+	return s.save(session)
+}
+
+// ExecAlways runs commands, it is called every time the config is loaded.
+func (s *Startup) ExecAlways(commands []*config.Exec) {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	s.run(commands, SourceExecAlways)
+}
+
+// Status returns the session and the commands that have been run.
+func (s *Startup) Status() *StartupStatus {
+	s.mtx.Lock()
+	defer s.mtx.Unlock()
+	return &StartupStatus{
+		Session:  s.session,
+		ExecRan:  s.execRan,
+		Commands: append([]*StartupCommand{}, s.commands...),
+	}
+}
+
+func (s *Startup) run(commands []*config.Exec, source string) {
+	for _, e := range commands {
+		c := &StartupCommand{
+			Command: e.Command,
+			Source:  source,
+			Started: time.Now(),
+		}
+		proc, err := s.processes.start(e.Command, &ExecOptions{NoStartupID: e.NoStartupID}, source)
+		if err != nil {
+			log.Printf("%s: %v", source, err)
+			c.Error = err.Error()
+		} else {
+			log.Printf("%s: started %s (pid %d)", source, e.Command, proc.PID)
+			c.PID = proc.PID
+		}
+		s.commands = append(s.commands, c)
+	}
+}
+
+func (s *Startup) savedSession() string {
+	if s.path == "" {
+		return ""
+	}
+	b, err := os.ReadFile(s.path)
+	if errors.Is(err, os.ErrNotExist) {
+		return ""
+	} else if err != nil {
+		log.Printf("exec: %v", err)
+		return ""
+	}
+	state := &startupState{}
+	err = json.Unmarshal(b, state)
+	if err != nil {
+		log.Printf("exec: %v", err)
+		return ""
+	}
+	return state.Session
+}
+
+func (s *Startup) save(session string) error {
+	if s.path == "" {
+		return nil
+	}
+	b, err := json.Marshal(&startupState{Session: session})
+	if err != nil {
+		return err
+	}
+	err = os.MkdirAll(path.Dir(s.path), 0o755)
+	if err != nil {
+		return err
+	}
+	return os.WriteFile(s.path, b, 0o644)
+}
@@ -0,0 +1,106 @@
+package run
+
+import (
+	"os"
+	"path"
+	"path/filepath"
+	"slices"
+	"syscall"
+	"testing"
+
+	"github.com/abibby/yabai3/config"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func setSessionID(t *testing.T, id string) {
+	old := sessionID
+	sessionID = func() (string, error) { return id, nil }
+	t.Cleanup(func() { sessionID = old })
+}
+
+func commands(c ...string) []*config.Exec {
+	execs := make([]*config.Exec, len(c))
+	for i, command := range c {
+		execs[i] = &config.Exec{Command: command}
+	}
+	return execs
+}
+
+func TestStartup_exec(t *testing.T) {
+	setSessionID(t, "session-1")
+	dir := t.TempDir()
+	state := path.Join(dir, "startup.json")
+	marker := path.Join(dir, "ran")
+
+	p := NewProcesses("")
+	s := NewStartup(state, p)
+	assert.NoError(t, s.Exec(commands("echo x >> "+marker)))
+	assert.NoError(t, s.Exec(commands("echo x >> "+marker)))
+	waitForExit(t, p)
+
+	// a restart in the same session doesn't run exec again
+	s = NewStartup(state, p)
+	assert.NoError(t, s.Exec(commands("echo x >> "+marker)))
+	waitForExit(t, p)
+	b, err := os.ReadFile(marker)
+	assert.NoError(t, err)
+	assert.Equal(t, "x\n", string(b))
+	assert.True(t, s.Status().ExecRan)
+	assert.Empty(t, s.Status().Commands)
+
+	setSessionID(t, "session-2")
+	s = NewStartup(state, p)
+	assert.NoError(t, s.Exec(commands("echo x >> "+marker)))
+	waitForExit(t, p)
+	b, err = os.ReadFile(marker)
+	assert.NoError(t, err)
+	assert.Equal(t, "x\nx\n", string(b))
+
+	status := s.Status()
+	assert.Equal(t, "session-2", status.Session)
+	if assert.Len(t, status.Commands, 1) {
+		assert.Equal(t, SourceExec, status.Commands[0].Source)
+		assert.NotZero(t, status.Commands[0].PID)
+	}
+}
+
+func TestStartup_execAlways(t *testing.T) {
+	p := NewProcesses("")
+	s := NewStartup("", p)
+	s.ExecAlways(commands("exec sleep 5"))
+	s.ExecAlways(commands("exec sleep 5"))
+
+	processes := p.List()
+	assert.Len(t, processes, 2)
+	for _, proc := range processes {
+		assert.Equal(t, SourceExecAlways, proc.Source)
+		assert.NoError(t, syscall.Kill(proc.PID, syscall.SIGTERM))
+	}
+	waitForExit(t, p)
+	assert.Len(t, s.Status().Commands, 2)
+}
+
+func TestStartup_noStartupID(t *testing.T) {
+	p := NewProcesses(t.TempDir())
+	s := NewStartup("", p)
+	s.ExecAlways([]*config.Exec{
+		{Command: `echo "id $DESKTOP_STARTUP_ID"`, NoStartupID: true},
+		{Command: `echo "id $DESKTOP_STARTUP_ID"`},
+	})
+	waitForExit(t, p)
+
+	logs, err := filepath.Glob(path.Join(p.logDir, "*.log"))
+	assert.NoError(t, err)
+	output := []string{}
+	for _, l := range logs {
+		b, err := os.ReadFile(l)
+		assert.NoError(t, err)
+		output = append(output, string(b))
+	}
+	slices.Sort(output)
+	if assert.Len(t, output, 2) {
+		assert.Equal(t, "id \n", output[0])
+		assert.Regexp(t, `^id yabai3/`, output[1])
+	}
+}
@@ -0,0 +1,20 @@
+package run
+
+import (
+	"os"
+	"path"
+)
+
+// StatePath returns the path of a file that keeps state between runs of
+// yabai3, in $XDG_STATE_HOME/yabai3 or ~/.local/state/yabai3.
+func StatePath(name string) (string, error) {
+	dir := os.Getenv("XDG_STATE_HOME")
+	if dir == "" {
+		home, err := os.UserHomeDir()
+		if err != nil {
+			return "", err
+		}
+		dir = path.Join(home, ".local/state")
+	}
+	return path.Join(dir, "yabai3", name), nil
+}
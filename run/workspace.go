@@ -0,0 +1,394 @@
+package run
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/abibby/salusa/di"
+	"github.com/abibby/yabai3/yabai"
+)
+
+// WorkspaceHistory remembers the workspace that had focus before the current
+// one for back_and_forth. yabai's recent space selector counts spaces focused
+// on other displays, which i3 doesn't.
+type WorkspaceHistory struct {
+	mtx      *sync.Mutex
+	current  string
+	previous string
+}
+
+func NewWorkspaceHistory() *WorkspaceHistory {
+	return &WorkspaceHistory{
+		mtx: &sync.Mutex{},
+	}
+}
+
+func RegisterWorkspaceHistory(ctx context.Context, h *WorkspaceHistory) {
+	di.RegisterSingleton(ctx, func() *WorkspaceHistory {
+		return h
+	})
+}
+
+// Focus records that the workspace called name has focus.
+func (h *WorkspaceHistory) Focus(name string) {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	if name == "" || name == h.current {
+		return
+	}
+	h.previous = h.current
+	h.current = name
+}
+
+// Previous returns the workspace that had focus before the current one.
+func (h *WorkspaceHistory) Previous() string {
+	h.mtx.Lock()
+	defer h.mtx.Unlock()
+	return h.previous
+}
+
+// spaceName returns the i3 name of a space, its label or its index if it
+// doesn't have one.
+func spaceName(s *yabai.Space) string {
+	if s.Label != "" {
+		return s.Label
+	}
+	return strconv.Itoa(s.Index)
+}
+
+// workspaceNumber returns the number a workspace name starts with the way i3
+// numbers workspaces called "1: web".
+func workspaceNumber(name string) (int, bool) {
+	end := 0
+	for end < len(name) && name[end] >= '0' && name[end] <= '9' {
+		end++
+	}
+	n, err := strconv.Atoi(name[:end])
+	return n, err == nil
+}
+
+func focusedSpace(spaces []*yabai.Space) *yabai.Space {
+	for _, s := range spaces {
+		if s.HasFocus {
+			return s
+		}
+	}
+	return nil
+}
+
+// findWorkspace resolves the arguments of a workspace command to a space:
+// next, prev, next_on_output, prev_on_output, back_and_forth, number <n> or a
+// workspace name. Names are matched against labels, a number matches the
+// space with that index as well. If there is no space for the workspace it
+// returns a nil space and the name to create it with, back_and_forth returns
+// neither if no other workspace has had focus yet.
+func findWorkspace(spaces []*yabai.Space, h *WorkspaceHistory, args []string) (*yabai.Space, string, error) {
+	current := focusedSpace(spaces)
+	if current == nil {
+		return nil, "", fmt.Errorf("no focused workspace")
+	}
+
+	switch args[0] {
+	case "next", "prev", "next_on_output", "prev_on_output":
+		if len(args) != 1 {
+			return nil, "", ErrUnknownCommand
+		}
+		s := cycleWorkspace(spaces, current, args[0])
+		return s, spaceName(s), nil
+	case "back_and_forth":
+		if len(args) != 1 {
+			return nil, "", ErrUnknownCommand
+		}
+		name := h.Previous()
+		if name == "" {
+			return nil, "", nil
+		}
+		return namedWorkspace(spaces, name), name, nil
+	case "number":
+		name := strings.Join(args[1:], " ")
+		n, ok := workspaceNumber(name)
+		if !ok {
+			return nil, "", fmt.Errorf("workspace number %s does not start with a number", name)
+		}
+		for _, s := range spaces {
+			if sn, ok := workspaceNumber(spaceName(s)); ok && sn == n {
+				return s, spaceName(s), nil
+			}
+		}
+		return nil, name, nil
+	}
+	name := strings.Join(args, " ")
+	return namedWorkspace(spaces, name), name, nil
+}
+
+func namedWorkspace(spaces []*yabai.Space, name string) *yabai.Space {
+	for _, s := range spaces {
+		if s.Label == name {
+			return s
+		}
+	}
+	if index, err := strconv.Atoi(name); err == nil {
+		for _, s := range spaces {
+			if s.Index == index {
+				return s
+			}
+		}
+	}
+	return nil
+}
+
+// workspaceSpace returns the space for the arguments of a workspace command,
+// creating it if it doesn't exist yet.
+func workspaceSpace(y yabai.Client, s *session, args []string) (*yabai.Space, error) {
+	spaces, err := y.QuerySpaces()
+	if err != nil {
+		return nil, err
+	}
+	target, name, err := findWorkspace(spaces, s.history, args)
+	if err != nil || target != nil || name == "" {
+		return target, err
+	}
+	return createWorkspace(y, s, name)
+}
+
+// createWorkspace adds a space labelled name to the output the config assigns
+// the workspace to, or to the focused display if it doesn't.
+func createWorkspace(y yabai.Client, s *session, name string) (*yabai.Space, error) {
+	active, err := y.QueryActiveSpace()
+	if err != nil {
+		return nil, err
+	}
+	d, err := getDisplay(y, active.DisplayIndex)
+	if err != nil {
+		return nil, err
+	}
+	declared := s.options.workspace(name)
+	if declared != nil {
+		if output, err := getDisplayFrom(y, declared.Outputs); err == nil {
+			d = output
+		}
+	}
+
+	err = y.Yabai("space", "--create", strconv.Itoa(d.Index))
+	if err != nil {
+		return nil, err
+	}
+	// the new space is the last one on the display
+	d, err = getDisplay(y, d.Index)
+	if err != nil {
+		return nil, err
+	}
+	index := d.SpaceIndexes[len(d.SpaceIndexes)-1]
+	err = y.Yabai("space", strconv.Itoa(index), "--label", name)
+	if err != nil {
+		return nil, err
+	}
+	if declared == nil {
+		s.created.add(name)
+	}
+
+	spaces, err := y.QuerySpaces()
+	if err != nil {
+		return nil, err
+	}
+	for _, sp := range spaces {
+		if sp.Index == index {
+			return sp, nil
+		}
+	}
+	return nil, fmt.Errorf("no workspace %s", name)
+}
+
+// cycleWorkspace returns the space after or before current in mission control
+// order, wrapping around at the ends. The _on_output variants only count
+// spaces on current's display.
+func cycleWorkspace(spaces []*yabai.Space, current *yabai.Space, direction string) *yabai.Space {
+	candidates := []*yabai.Space{}
+	for _, s := range spaces {
+		if strings.HasSuffix(direction, "_on_output") && s.DisplayIndex != current.DisplayIndex {
+			continue
+		}
+		candidates = append(candidates, s)
+	}
+	for i, s := range candidates {
+		if s.ID != current.ID {
+			continue
+		}
+		if strings.HasPrefix(direction, "next") {
+			return candidates[(i+1)%len(candidates)]
+		}
+		return candidates[(i+len(candidates)-1)%len(candidates)]
+	}
+	return current
+}
+
+// autoBackAndForth returns the previous workspace instead of target if
+// target is the focused workspace and was named by name or number. target is
+// nil if no other workspace has had focus yet.
+func autoBackAndForth(y yabai.Client, s *session, args []string, current, target *yabai.Space) (*yabai.Space, error) {
+	if target.ID != current.ID {
+		return target, nil
+	}
+	switch args[0] {
+	case "next", "prev", "next_on_output", "prev_on_output", "back_and_forth":
+		return target, nil
+	}
+	return workspaceSpace(y, s, []string{"back_and_forth"})
+}
+
+// workspaceArgs removes --no-auto-back-and-forth from the arguments of a
+// workspace command and reports whether workspace_auto_back_and_forth
+// applies.
+func workspaceArgs(s *session, args []string) ([]string, bool) {
+	auto := s.options.workspaceAutoBackAndForth()
+	if len(args) > 0 && args[0] == "--no-auto-back-and-forth" {
+		auto = false
+		args = args[1:]
+	}
+	return args, auto
+}
+
+// runWorkspace implements `workspace [--no-auto-back-and-forth] <target>`.
+// With workspace_auto_back_and_forth, switching to the focused workspace by
+// name or number goes back to the previous one instead. The workspace history
+// is updated by the server when yabai signals the switch.
+func runWorkspace(s *session) runner {
+	return func(y yabai.Client, c []string) error {
+		args, auto := workspaceArgs(s, c[1:])
+		if len(args) == 0 {
+			return ErrUnknownCommand
+		}
+
+		current, err := y.QueryActiveSpace()
+		if err != nil {
+			return err
+		}
+		target, err := workspaceSpace(y, s, args)
+		if err != nil || target == nil {
+			return err
+		}
+		if auto {
+			target, err = autoBackAndForth(y, s, args, current, target)
+			if err != nil || target == nil {
+				return err
+			}
+		}
+		if target.ID == current.ID {
+			return nil
+		}
+		return y.Yabai("space", "--focus", strconv.Itoa(target.Index))
+	}
+}
+
+// moveToWorkspace moves the focused window to the workspace the arguments of
+// a workspace command resolve to. Like i3 it follows
+// workspace_auto_back_and_forth.
+func moveToWorkspace(y yabai.Client, s *session, args []string) error {
+	args, auto := workspaceArgs(s, args)
+	if len(args) == 0 {
+		return ErrUnknownCommand
+	}
+	w, err := y.QueryActiveWindow()
+	if err != nil {
+		return err
+	}
+	target, err := workspaceSpace(y, s, args)
+	if err != nil || target == nil {
+		return err
+	}
+	if auto {
+		current, err := focusedClient(y).QueryActiveSpace()
+		if err != nil {
+			return err
+		}
+		target, err = autoBackAndForth(y, s, args, current, target)
+		if err != nil || target == nil {
+			return err
+		}
+	}
+	if w.Space == target.Index {
+		return nil
+	}
+	return y.Yabai("window", "--space", strconv.Itoa(target.Index))
+}
+
+// CreatedWorkspaces are the workspaces yabai3 created on demand because no
+// space had their name. They are destroyed again once they are empty.
+type CreatedWorkspaces struct {
+	mtx   *sync.Mutex
+	names map[string]struct{}
+}
+
+func NewCreatedWorkspaces() *CreatedWorkspaces {
+	return &CreatedWorkspaces{
+		mtx:   &sync.Mutex{},
+		names: map[string]struct{}{},
+	}
+}
+
+func RegisterCreatedWorkspaces(ctx context.Context, c *CreatedWorkspaces) {
+	di.RegisterSingleton(ctx, func() *CreatedWorkspaces {
+		return c
+	})
+}
+
+func (c *CreatedWorkspaces) add(name string) {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	c.names[name] = struct{}{}
+}
+
+// CollectWorkspaces destroys the spaces of created workspaces that have no
+// windows and aren't visible. Workspaces the config declares are kept even if
+// they were created on demand before it was loaded.
+func CollectWorkspaces(y yabai.Client, c *CreatedWorkspaces, o *Options) error {
+	c.mtx.Lock()
+	defer c.mtx.Unlock()
+	if len(c.names) == 0 {
+		return nil
+	}
+
+	spaces, err := y.QuerySpaces()
+	if err != nil {
+		return err
+	}
+	labels := map[string]*yabai.Space{}
+	for _, s := range spaces {
+		if s.Label != "" {
+			labels[s.Label] = s
+		}
+	}
+
+	// destroying a space shifts the index of every space after it, go from
+	// the back so the indexes stay valid
+	destroy := []*yabai.Space{}
+	for name := range c.names {
+		s, ok := labels[name]
+		if !ok || o.workspace(name) != nil {
+			delete(c.names, name)
+			continue
+		}
+		if len(s.WindowIDs) == 0 && !s.IsVisible {
+			destroy = append(destroy, s)
+		}
+	}
+	slices.SortFunc(destroy, func(a, b *yabai.Space) int {
+		return b.Index - a.Index
+	})
+
+	errs := []error{}
+	for _, s := range destroy {
+		err := y.Yabai("space", strconv.Itoa(s.Index), "--destroy")
+		if err != nil {
+			errs = append(errs, fmt.Errorf("destroy workspace %s: %w", s.Label, err))
+			continue
+		}
+		delete(c.names, s.Label)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,144 @@
+package run
+
+import (
+	"context"
+	"testing"
+
+	"github.com/abibby/salusa/di"
+	"github.com/abibby/yabai3/config"
+	"github.com/abibby/yabai3/yabai"
+	"github.com/stretchr/testify/assert"
+)
+
+// recordFocus adds the focused workspace to the history the way the server
+// does when yabai signals that the space changed.
+func recordFocus(t *testing.T, ctx context.Context, f *yabai.Fake) {
+	t.Helper()
+	h, err := di.Resolve[*WorkspaceHistory](ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+	s, err := f.QueryActiveSpace()
+	assert.NoError(t, err)
+	h.Focus(spaceName(s))
+}
+
+func TestWorkspace(t *testing.T) {
+	f := newTwoDisplayFake()
+	f.AddSpace(1)
+	assert.NoError(t, f.Yabai("space", "2", "--label", "2: web"))
+	ctx := newTestContext(f)
+	recordFocus(t, ctx, f)
+	workspace := func(c ...string) int {
+		t.Helper()
+		assert.NoError(t, Command(ctx, append([]string{"workspace"}, c...), noChangeMode, noRestart))
+		recordFocus(t, ctx, f)
+		s, err := f.QueryActiveSpace()
+		assert.NoError(t, err)
+		return s.Index
+	}
+
+	assert.Equal(t, 2, workspace("next"))
+	assert.Equal(t, 3, workspace("next"))
+	assert.Equal(t, 1, workspace("next"))
+	assert.Equal(t, 2, workspace("prev_on_output"))
+	assert.Equal(t, 1, workspace("back_and_forth"))
+	assert.Equal(t, 2, workspace("2:", "web"))
+	assert.Equal(t, 3, workspace("number", "3"))
+	assert.Equal(t, 2, workspace("number", "2"))
+	// without workspace_auto_back_and_forth the focused workspace stays
+	assert.Equal(t, 2, workspace("2: web"))
+}
+
+func TestWorkspace_create(t *testing.T) {
+	f := newTwoDisplayFake()
+	ctx := newTestContext(f)
+	options, err := di.Resolve[*Options](ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+	options.Load(&config.Config{
+		Workspaces: []*config.Workspace{{Name: "chat", Outputs: []string{"right"}}},
+	})
+	label := func(index int) string {
+		t.Helper()
+		spaces, err := f.QuerySpaces()
+		assert.NoError(t, err)
+		for _, s := range spaces {
+			if s.Index == index {
+				return s.Label
+			}
+		}
+		return ""
+	}
+
+	assert.NoError(t, Command(ctx, []string{"workspace", "notes"}, noChangeMode, noRestart))
+	s, err := f.QueryActiveSpace()
+	assert.NoError(t, err)
+	assert.Equal(t, "notes", s.Label)
+	assert.Equal(t, 1, s.DisplayIndex)
+
+	// declared workspaces are created on their output
+	assert.NoError(t, Command(ctx, []string{"workspace", "1"}, noChangeMode, noRestart))
+	assert.NoError(t, Command(ctx, []string{"move", "container", "to", "workspace", "chat"}, noChangeMode, noRestart))
+	assert.Equal(t, "chat", label(windowSpace(t, f, "a")))
+	assert.Equal(t, 4, windowSpace(t, f, "a"))
+
+	// notes is empty and hidden, chat has a window and is declared anyway
+	assert.NoError(t, Command(ctx, []string{"move", "container", "to", "workspace", "number", "7"}, noChangeMode, noRestart))
+	created, err := di.Resolve[*CreatedWorkspaces](ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+	assert.NoError(t, CollectWorkspaces(f, created, options))
+	spaces, err := f.QuerySpaces()
+	assert.NoError(t, err)
+	labels := []string{}
+	for _, s := range spaces {
+		labels = append(labels, s.Label)
+	}
+	assert.Equal(t, []string{"", "7", "", "chat"}, labels)
+}
+
+func TestWorkspace_autoBackAndForth(t *testing.T) {
+	f := newTwoDisplayFake()
+	ctx := newTestContext(f)
+	options, err := di.Resolve[*Options](ctx)
+	if !assert.NoError(t, err) {
+		return
+	}
+	options.Load(&config.Config{WorkspaceAutoBackAndForth: true})
+	recordFocus(t, ctx, f)
+	workspace := func(c ...string) int {
+		t.Helper()
+		assert.NoError(t, Command(ctx, append([]string{"workspace"}, c...), noChangeMode, noRestart))
+		recordFocus(t, ctx, f)
+		s, err := f.QueryActiveSpace()
+		assert.NoError(t, err)
+		return s.Index
+	}
+
+	assert.Equal(t, 2, workspace("2"))
+	assert.Equal(t, 1, workspace("2"))
+	assert.Equal(t, 1, workspace("--no-auto-back-and-forth", "1"))
+	assert.Equal(t, 2, workspace("number", "1"))
+
+	// move container follows workspace_auto_back_and_forth as well
+	assert.NoError(t, Command(ctx, []string{"move", "container", "to", "workspace", "2"}, noChangeMode, noRestart))
+	assert.Equal(t, 1, windowSpace(t, f, "c"))
+	assert.Equal(t, 1, workspace("1"))
+	assert.NoError(t, Command(ctx, []string{"move", "container", "to", "workspace", "--no-auto-back-and-forth", "1"}, noChangeMode, noRestart))
+	assert.Equal(t, 1, windowSpace(t, f, "a"))
+}
+
+func TestMoveToWorkspace(t *testing.T) {
+	f := newTwoDisplayFake()
+	f.AddSpace(2)
+	ctx := newTestContext(f)
+
+	assert.NoError(t, Command(ctx, []string{"move", "container", "to", "workspace", "number", "3"}, noChangeMode, noRestart))
+	assert.Equal(t, 3, windowSpace(t, f, "a"))
+
+	assert.NoError(t, Command(ctx, []string{"move", "container", "to", "workspace", "next"}, noChangeMode, noRestart))
+	assert.Equal(t, 2, windowSpace(t, f, "b"))
+}
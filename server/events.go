@@ -0,0 +1,158 @@
+package server
+
+import (
+	"log"
+	"sync"
+
+	"github.com/abibby/salusa/set"
+)
+
+type Event struct {
+	Type    MessageType
+	Payload any
+}
+
+var eventNames = map[string]MessageType{
+	"workspace":        EventWorkspace,
+	"output":           EventOutput,
+	"mode":             EventMode,
+	"window":           EventWindow,
+	"barconfig_update": EventBarconfigUpdate,
+	"binding":          EventBinding,
+	"shutdown":         EventShutdown,
+	"tick":             EventTick,
+	"config":           EventConfig,
+}
+
+// Subscription receives every event published for the types it was
+// subscribed to.
+type Subscription struct {
+	types set.Set[MessageType]
+	send  func(e *Event) error
+}
+
+// EventBus fans events out to subscribers. Publish delivers synchronously so
+// an event has been written to every subscriber by the time it returns, a
+// subscriber that can't keep up is dropped.
+type EventBus struct {
+	mtx           *sync.Mutex
+	subscriptions set.Set[*Subscription]
+}
+
+func NewEventBus() *EventBus {
+	return &EventBus{
+		mtx:           &sync.Mutex{},
+		subscriptions: set.New[*Subscription](),
+	}
+}
+
+func (b *EventBus) Subscribe(types []MessageType, send func(e *Event) error) *Subscription {
+	sub := &Subscription{
+		types: set.New[MessageType](),
+		send:  send,
+	}
+	for _, t := range types {
+		sub.types.Add(t)
+	}
+
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.subscriptions.Add(sub)
+	return sub
+}
+
+func (b *EventBus) Unsubscribe(sub *Subscription) {
+	b.mtx.Lock()
+	defer b.mtx.Unlock()
+	b.subscriptions.Delete(sub)
+}
+
+func (b *EventBus) Publish(t MessageType, payload any) {
+	b.mtx.Lock()
+	subs := []*Subscription{}
+	for sub := range b.subscriptions {
+		if sub.types.Has(t) {
+			subs = append(subs, sub)
+		}
+	}
+	b.mtx.Unlock()
+
+	e := &Event{
+		Type:    t,
+		Payload: payload,
+	}
+	for _, sub := range subs {
+		err := sub.send(e)
+		if err != nil {
+			log.Printf("i3-msg server: event: %v", err)
+			b.Unsubscribe(sub)
+		}
+	}
+}
+
+type TickEvent struct {
+	First   bool   `json:"first"`
+	Payload string `json:"payload"`
+}
+
+func (s *I3MsgServer) sendTick(w *Writer, r *Request) error {
+	s.events.Publish(EventTick, &TickEvent{
+		First:   false,
+		Payload: r.Message,
+	})
+	return w.Encode(map[string]bool{"success": true})
+}
+
+type Binding struct {
+	Command        string   `json:"command"`
+	EventStateMask []string `json:"event_state_mask"`
+	InputCode      int      `json:"input_code"`
+	Symbol         string   `json:"symbol"`
+	InputType      string   `json:"input_type"`
+}
+
+type BindingEvent struct {
+	Change  string   `json:"change"`
+	Binding *Binding `json:"binding"`
+}
+
+// BindingTriggered sends a binding event for a key binding that was just run.
+func (s *I3MsgServer) BindingTriggered(command, symbol string, modifiers []string) {
+	if modifiers == nil {
+		modifiers = []string{}
+	}
+	s.events.Publish(EventBinding, &BindingEvent{
+		Change: "run",
+		Binding: &Binding{
+			Command:        command,
+			EventStateMask: modifiers,
+			InputCode:      0,
+			Symbol:         symbol,
+			InputType:      "keyboard",
+		},
+	})
+}
+
+// Shutdown sends a shutdown event, change is either "restart" or "exit".
+func (s *I3MsgServer) Shutdown(change string) {
+	s.events.Publish(EventShutdown, map[string]string{"change": change})
+}
+
+type ConfigEvent struct {
+	Change  string `json:"change"`
+	Success bool   `json:"success"`
+	Error   string `json:"error,omitempty"`
+}
+
+// ConfigReloaded sends a config event with the result of reloading the
+// config.
+func (s *I3MsgServer) ConfigReloaded(err error) {
+	e := &ConfigEvent{
+		Change:  "reload",
+		Success: err == nil,
+	}
+	if err != nil {
+		e.Error = err.Error()
+	}
+	s.events.Publish(EventConfig, e)
+}
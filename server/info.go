@@ -0,0 +1,158 @@
+package server
+
+import (
+	"slices"
+	"strconv"
+	"strings"
+
+	"github.com/abibby/salusa/di"
+	"github.com/abibby/yabai3/config"
+	"github.com/abibby/yabai3/run"
+	"github.com/abibby/yabai3/yabai"
+)
+
+type Output struct {
+	Name             string  `json:"name"`
+	Active           bool    `json:"active"`
+	Primary          bool    `json:"primary"`
+	CurrentWorkspace *string `json:"current_workspace"`
+	Rect             Rect    `json:"rect"`
+}
+
+type Version struct {
+	Major                int    `json:"major"`
+	Minor                int    `json:"minor"`
+	Patch                int    `json:"patch"`
+	HumanReadable        string `json:"human_readable"`
+	LoadedConfigFileName string `json:"loaded_config_file_name"`
+}
+
+type IncludedConfig struct {
+	Path        string `json:"path"`
+	RawContents string `json:"raw_contents"`
+}
+
+type Config struct {
+	Config          string            `json:"config"`
+	IncludedConfigs []*IncludedConfig `json:"included_configs"`
+}
+
+// SetConfig replaces the config reported by get_config, get_version and
+// get_binding_modes.
+func (s *I3MsgServer) SetConfig(cfg *config.Config) {
+	s.configMtx.Lock()
+	defer s.configMtx.Unlock()
+	s.config = cfg
+}
+
+func (s *I3MsgServer) getConfigFile() *config.Config {
+	s.configMtx.Lock()
+	defer s.configMtx.Unlock()
+	return s.config
+}
+
+func (s *I3MsgServer) getOutputs(w *Writer, r *Request) error {
+	y, err := di.Resolve[yabai.Client](r.Context())
+	if err != nil {
+		return err
+	}
+	displays, err := y.QueryDisplays()
+	if err != nil {
+		return err
+	}
+	spaces, err := y.QuerySpaces()
+	if err != nil {
+		return err
+	}
+
+	outputs := make([]*Output, len(displays))
+	for i, d := range displays {
+		var current *string
+		for _, s := range spaces {
+			if s.DisplayIndex == d.Index && s.IsVisible {
+				name := workspaceName(s)
+				current = &name
+				break
+			}
+		}
+		outputs[i] = &Output{
+			Name:             outputName(d),
+			Active:           true,
+			Primary:          d.Index == 1,
+			CurrentWorkspace: current,
+			Rect:             frameRect(d.Frame),
+		}
+	}
+	return w.Encode(outputs)
+}
+
+func (s *I3MsgServer) getMarks(w *Writer, r *Request) error {
+	return w.Encode(s.marks.List())
+}
+
+func (s *I3MsgServer) getProcesses(w *Writer, r *Request) error {
+	p, err := di.Resolve[*run.Processes](r.Context())
+	if err != nil {
+		return err
+	}
+	return w.Encode(p.List())
+}
+
+func (s *I3MsgServer) getStartup(w *Writer, r *Request) error {
+	startup, err := di.Resolve[*run.Startup](r.Context())
+	if err != nil {
+		return err
+	}
+	return w.Encode(startup.Status())
+}
+
+func (s *I3MsgServer) getBindingModes(w *Writer, r *Request) error {
+	modes := []string{}
+	for _, m := range s.getConfigFile().Modes {
+		modes = append(modes, m.Name)
+	}
+	slices.SortFunc(modes, func(a, b string) int {
+		if a == "default" {
+			return -1
+		} else if b == "default" {
+			return 1
+		}
+		return strings.Compare(a, b)
+	})
+	return w.Encode(modes)
+}
+
+func (s *I3MsgServer) getVersion(w *Writer, r *Request) error {
+	v := &Version{
+		HumanReadable:        s.version,
+		LoadedConfigFileName: s.getConfigFile().Path,
+	}
+	parts := strings.SplitN(s.version, ".", 3)
+	for i, p := range parts {
+		n, _ := strconv.Atoi(p)
+		switch i {
+		case 0:
+			v.Major = n
+		case 1:
+			v.Minor = n
+		case 2:
+			v.Patch = n
+		}
+	}
+	return w.Encode(v)
+}
+
+func (s *I3MsgServer) getConfig(w *Writer, r *Request) error {
+	cfg := s.getConfigFile()
+	reply := &Config{
+		Config:          cfg.Source,
+		IncludedConfigs: []*IncludedConfig{},
+	}
+	for _, include := range cfg.Includes {
+		reply.IncludedConfigs = append(reply.IncludedConfigs, &IncludedConfig{
+			Path:        include.Path,
+			RawContents: include.Source,
+		})
+	}
+	return w.Encode(reply)
+}
@@ -0,0 +1,211 @@
+package server
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/user"
+	"sync"
+	"time"
+)
+
+// https://i3wm.org/docs/ipc.html#_establishing_a_connection
+
+const Magic = "i3-ipc"
+
+type MessageType uint32
+
+const (
+	MessageRunCommand MessageType = iota
+	MessageGetWorkspaces
+	MessageSubscribe
+	MessageGetOutputs
+	MessageGetTree
+	MessageGetMarks
+	MessageGetBarConfig
+	MessageGetVersion
+	MessageGetBindingModes
+	MessageGetConfig
+	MessageSendTick
+	MessageSync
+	MessageGetBindingState
+)
+
+// MessageYabaiSignal isn't part of i3's protocol. It is sent by `yabai3
+// signal` when yabai fires one of the signals registered on startup.
+const MessageYabaiSignal MessageType = 0x7961
+
+// MessageGetProcesses isn't part of i3's protocol. The reply is the list of
+// processes started with exec that are still running.
+const MessageGetProcesses MessageType = 0x7962
+
+// MessageGetStartup isn't part of i3's protocol. The reply is the exec and
+// exec_always commands from the config that have been run.
+const MessageGetStartup MessageType = 0x7963
+
+const eventMask = MessageType(1 << 31)
+
+const (
+	EventWorkspace MessageType = eventMask | iota
+	EventOutput
+	EventMode
+	EventWindow
+	EventBarconfigUpdate
+	EventBinding
+	EventShutdown
+	EventTick
+)
+
+// EventConfig isn't part of i3's protocol. It is sent after yabai3 tries to
+// reload its config.
+const EventConfig MessageType = eventMask | 0x7961
+
+var messageTypeNames = map[MessageType]string{
+	MessageRunCommand:      "command",
+	MessageGetWorkspaces:   "get_workspaces",
+	MessageSubscribe:       "subscribe",
+	MessageGetOutputs:      "get_outputs",
+	MessageGetTree:         "get_tree",
+	MessageGetMarks:        "get_marks",
+	MessageGetBarConfig:    "get_bar_config",
+	MessageGetVersion:      "get_version",
+	MessageGetBindingModes: "get_binding_modes",
+	MessageGetConfig:       "get_config",
+	MessageSendTick:        "send_tick",
+	MessageSync:            "sync",
+	MessageGetBindingState: "get_binding_state",
+	MessageYabaiSignal:     "yabai_signal",
+	MessageGetProcesses:    "get_processes",
+	MessageGetStartup:      "get_startup",
+}
+
+func (t MessageType) String() string {
+	if name, ok := messageTypeNames[t]; ok {
+		return name
+	}
+	return fmt.Sprintf("unknown(%d)", uint32(t))
+}
+
+// ParseMessageType converts the names accepted by `i3-msg -t` to a message
+// type.
+func ParseMessageType(name string) (MessageType, bool) {
+	if name == "run_command" {
+		return MessageRunCommand, true
+	}
+	for t, n := range messageTypeNames {
+		if n == name {
+			return t, true
+		}
+	}
+	return 0, false
+}
+
+// SocketPath returns the socket yabai3 listens on. I3SOCK takes precedence so
+// clients started by yabai3 always find the right daemon.
+func SocketPath() string {
+	if p := os.Getenv("I3SOCK"); p != "" {
+		return p
+	}
+	name := os.Getenv("USER")
+	if name == "" {
+		if u, err := user.Current(); err == nil {
+			name = u.Username
+		}
+	}
+	return fmt.Sprintf("/tmp/yabai3_%s.socket", name)
+}
+
+// WriteMessage writes a single framed message: the magic string, the payload
+// length, the message type and the payload.
+func WriteMessage(w io.Writer, t MessageType, payload []byte) error {
+	b := make([]byte, 0, len(Magic)+8+len(payload))
+	b = append(b, Magic...)
+	b = binary.NativeEndian.AppendUint32(b, uint32(len(payload)))
+	b = binary.NativeEndian.AppendUint32(b, uint32(t))
+	b = append(b, payload...)
+	_, err := w.Write(b)
+	return err
+}
+
+// ReadMessage reads a single framed message.
+func ReadMessage(r io.Reader) (MessageType, []byte, error) {
+	header := make([]byte, len(Magic)+8)
+	_, err := io.ReadFull(r, header)
+	if err != nil {
+		return 0, nil, err
+	}
+	if string(header[:len(Magic)]) != Magic {
+		return 0, nil, fmt.Errorf("invalid magic %q", header[:len(Magic)])
+	}
+	length := binary.NativeEndian.Uint32(header[len(Magic):])
+	t := MessageType(binary.NativeEndian.Uint32(header[len(Magic)+4:]))
+
+	payload := make([]byte, length)
+	_, err = io.ReadFull(r, payload)
+	if err != nil {
+		return 0, nil, err
+	}
+	return t, payload, nil
+}
+
+// Writer json encodes replies and events as framed messages. Writers for the
+// same connection share a mutex so events and replies never interleave.
+type Writer struct {
+	w       io.Writer
+	mtx     *sync.Mutex
+	msgType MessageType
+}
+
+func NewWriter(w io.Writer, mtx *sync.Mutex, t MessageType) *Writer {
+	return &Writer{
+		w:       w,
+		mtx:     mtx,
+		msgType: t,
+	}
+}
+
+func (w *Writer) Encode(v any) error {
+	return w.write(w.msgType, v)
+}
+
+// eventTimeout is how long writing an event to a subscriber can take. A
+// subscriber that stops reading is dropped instead of blocking whatever
+// published the event.
+const eventTimeout = time.Second
+
+func (w *Writer) Event(t MessageType, v any) error {
+	conn, ok := w.w.(net.Conn)
+	if !ok {
+		return w.write(t, v)
+	}
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	err = conn.SetWriteDeadline(time.Now().Add(eventTimeout))
+	if err != nil {
+		return err
+	}
+	err = WriteMessage(conn, t, b)
+	if err != nil {
+		// a partly written event leaves the connection unusable
+		conn.Close()
+		return err
+	}
+	return conn.SetWriteDeadline(time.Time{})
+}
+
+func (w *Writer) write(t MessageType, v any) error {
+	b, err := json.Marshal(v)
+	if err != nil {
+		return err
+	}
+	w.mtx.Lock()
+	defer w.mtx.Unlock()
+	return WriteMessage(w.w, t, b)
+}
@@ -5,13 +5,17 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
+	"io"
 	"log"
 	"net"
-	"net/http"
+	"os"
+	"slices"
 	"sync"
 
+	"github.com/abibby/salusa/di"
 	"github.com/abibby/salusa/set"
-	"github.com/abibby/yabai3/badparser"
+	"github.com/abibby/yabai3/config"
+	"github.com/abibby/yabai3/i3parser"
 	"github.com/abibby/yabai3/run"
 	"github.com/abibby/yabai3/yabai"
 )
@@ -37,36 +41,117 @@ type CommandResult struct {
 	*I3msgError
 }
 
-const PORT = 3141
-
 type I3MsgServer struct {
 	listener   net.Listener
+	socketPath string
 	changeMode func(mode string) error
 	restart    func() error
+	version    string
+
+	configMtx *sync.Mutex
+	config    *config.Config
+
+	events *EventBus
+
+	windowRulesMtx *sync.Mutex
+	windowRules    map[int]set.Set[any]
 
-	modeChangeEventsMtx      *sync.Mutex
-	modeChangeEvents         set.Set[chan any]
-	workspaceChangeEventsMtx *sync.Mutex
-	workspaceChangeEvents    set.Set[chan any]
+	yabai    yabai.Client
+	layouts  *run.Layouts
+	marks    *run.Marks
+	history  *run.WorkspaceHistory
+	created  *run.CreatedWorkspaces
+	options  *run.Options
+	stateMtx *sync.Mutex
+	state    *state
 }
 
-func New() *I3MsgServer {
+func New(version string) *I3MsgServer {
 	return &I3MsgServer{
-		modeChangeEventsMtx: &sync.Mutex{},
-		modeChangeEvents:    set.New[chan any](),
+		version:   version,
+		configMtx: &sync.Mutex{},
+		config:    &config.Config{},
+		events:    NewEventBus(),
+		stateMtx:  &sync.Mutex{},
+
+		windowRulesMtx: &sync.Mutex{},
+		windowRules:    map[int]set.Set[any]{},
 	}
 }
 
+// Start listens for i3 IPC connections on SocketPath and exports I3SOCK so
+// every process started by yabai3 can find it.
 func (s *I3MsgServer) Start(ctx context.Context, changeMode func(mode string) error, restart func() error) error {
 
 	s.changeMode = changeMode
 	s.restart = restart
 
-	l, err := net.Listen("tcp4", fmt.Sprintf(":%d", PORT))
+	y, err := di.Resolve[yabai.Client](ctx)
+	if err != nil {
+		return err
+	}
+	s.yabai = y
+
+	layouts, err := di.Resolve[*run.Layouts](ctx)
 	if err != nil {
 		return err
 	}
+	s.layouts = layouts
+
+	marks, err := di.Resolve[*run.Marks](ctx)
+	if err != nil {
+		return err
+	}
+	s.marks = marks
+
+	history, err := di.Resolve[*run.WorkspaceHistory](ctx)
+	if err != nil {
+		return err
+	}
+	s.history = history
+
+	created, err := di.Resolve[*run.CreatedWorkspaces](ctx)
+	if err != nil {
+		return err
+	}
+	s.created = created
+
+	options, err := di.Resolve[*run.Options](ctx)
+	if err != nil {
+		return err
+	}
+	s.options = options
+
+	socketPath := SocketPath()
+	err = removeStaleSocket(socketPath)
+	if err != nil {
+		return err
+	}
+
+	l, err := net.Listen("unix", socketPath)
+	if err != nil {
+		return err
+	}
+	err = os.Chmod(socketPath, 0o600)
+	if err != nil {
+		l.Close()
+		return err
+	}
 	s.listener = l
+	s.socketPath = socketPath
+
+	err = os.Setenv("I3SOCK", socketPath)
+	if err != nil {
+		l.Close()
+		return err
+	}
+
+	err = s.addSignals()
+	if err != nil {
+		l.Close()
+		return err
+	}
+	s.Refresh()
 
 	go func() {
 		defer l.Close()
@@ -76,7 +161,9 @@ func (s *I3MsgServer) Start(ctx context.Context, changeMode func(mode string) er
 
 		for {
 			c, err := l.Accept()
-			if err != nil {
+			if errors.Is(err, net.ErrClosed) {
+				return
+			} else if err != nil {
 				log.Printf("i3-msg server: accept: %v", err)
 				return
 			}
@@ -86,74 +173,137 @@ func (s *I3MsgServer) Start(ctx context.Context, changeMode func(mode string) er
 	return nil
 }
 
+// removeStaleSocket removes a socket left behind by a previous run, refusing
+// to touch it if another daemon is still listening.
+func removeStaleSocket(socketPath string) error {
+	c, err := net.Dial("unix", socketPath)
+	if err == nil {
+		c.Close()
+		return fmt.Errorf("i3-msg server: %s is already in use", socketPath)
+	}
+	err = os.Remove(socketPath)
+	if err != nil && !errors.Is(err, os.ErrNotExist) {
+		return err
+	}
+	return nil
+}
+
 type Request struct {
-	Type    string `json:"type"`
-	Message string `json:"message"`
-	Monitor bool   `json:"monitor"`
+	Type    string
+	Message string
 	ctx     context.Context
+	conn    *connection
 }
 
 func (r *Request) Context() context.Context {
 	return r.ctx
 }
 
+// connection is the state shared by every request on a single client
+// connection.
+type connection struct {
+	conn          net.Conn
+	mtx           *sync.Mutex
+	subscriptions []*Subscription
+}
+
 func (s *I3MsgServer) rootHandler(ctx context.Context, c net.Conn) {
 	defer c.Close()
 
-	r := json.NewDecoder(c)
-	w := json.NewEncoder(c)
-
 	ctx, cancel := context.WithCancel(ctx)
 	defer cancel()
 
+	conn := &connection{
+		conn: c,
+		mtx:  &sync.Mutex{},
+	}
+	defer func() {
+		for _, sub := range conn.subscriptions {
+			s.events.Unsubscribe(sub)
+		}
+	}()
+
 	for {
-		req := &Request{}
-		err := r.Decode(req)
-		if errors.Is(err, net.ErrClosed) {
+		t, payload, err := ReadMessage(c)
+		if errors.Is(err, io.EOF) || errors.Is(err, net.ErrClosed) {
 			return
 		} else if err != nil {
 			log.Printf("i3-msg server: handle: %v", err)
 			return
 		}
 
-		go func() {
-			req.ctx = ctx
-
-			err = s.processRequest(w, req)
-			if err != nil {
-				log.Printf("i3-msg server: handle: %v", err)
-				return
-			}
-			if !req.Monitor {
-				c.Close()
-				return
-			}
-		}()
+		req := &Request{
+			Type:    t.String(),
+			Message: string(payload),
+			ctx:     ctx,
+			conn:    conn,
+		}
+		err = s.processRequest(NewWriter(c, conn.mtx, t), req)
+		if err != nil {
+			log.Printf("i3-msg server: handle: %v", err)
+			return
+		}
 	}
 }
 
-func (s *I3MsgServer) processRequest(w *json.Encoder, r *Request) error {
+func (s *I3MsgServer) processRequest(w *Writer, r *Request) error {
 	switch r.Type {
 	case "command":
 		return s.command(w, r)
 	case "get_workspaces":
 		return s.getWorkspaces(w, r)
+	case "get_tree":
+		return s.getTree(w, r)
+	case "get_outputs":
+		return s.getOutputs(w, r)
+	case "get_marks":
+		return s.getMarks(w, r)
+	case "get_binding_modes":
+		return s.getBindingModes(w, r)
+	case "get_version":
+		return s.getVersion(w, r)
+	case "get_config":
+		return s.getConfig(w, r)
 	case "subscribe":
 		return s.subscribe(w, r)
+	case "send_tick":
+		return s.sendTick(w, r)
+	case "yabai_signal":
+		return s.yabaiSignal(w, r)
+	case "get_processes":
+		return s.getProcesses(w, r)
+	case "get_startup":
+		return s.getStartup(w, r)
 	default:
-		return fmt.Errorf("i3-msg server: handle: invalid type: %s", r.Type)
+		// i3 replies to message types it doesn't know instead of closing the
+		// connection, clients like bars send sync and get_bar_config
+		return w.Encode(map[string]any{
+			"success": false,
+			"error":   fmt.Sprintf("unsupported message type %s", r.Type),
+		})
 	}
 }
 
 func (s *I3MsgServer) Close() error {
 	errs := []error{}
+	if s.yabai != nil {
+		err := s.removeSignals()
+		if err != nil {
+			errs = append(errs, err)
+		}
+	}
 	if s.listener != nil {
 		err := s.listener.Close()
 		if err != nil {
 			errs = append(errs, err)
 		}
+		err = os.Remove(s.socketPath)
+		if err != nil && !errors.Is(err, os.ErrNotExist) {
+			errs = append(errs, err)
+		}
 	}
 	s.listener = nil
+	s.yabai = nil
 	s.restart = nil
 	s.changeMode = nil
 	if len(errs) > 0 {
@@ -163,14 +313,10 @@ func (s *I3MsgServer) Close() error {
 }
 
 func (s *I3MsgServer) ModeChanged(mode string) {
-	s.modeChangeEventsMtx.Lock()
-	defer s.modeChangeEventsMtx.Unlock()
-	for e := range s.modeChangeEvents {
-		e <- map[string]any{
-			"change":       mode,
-			"pango_markup": false,
-		}
-	}
+	s.events.Publish(EventMode, map[string]any{
+		"change":       mode,
+		"pango_markup": false,
+	})
 }
 
 type I3MsgWorkspace struct {
@@ -184,69 +330,53 @@ type WorkspaceChangeEvent struct {
 	Old     *I3MsgWorkspace `json:"old"`
 }
 
-func (s *I3MsgServer) WorkspaceChanged(workspace *Workspace) {
-	s.workspaceChangeEventsMtx.Lock()
-	defer s.workspaceChangeEventsMtx.Unlock()
-	for e := range s.workspaceChangeEvents {
-		e <- &WorkspaceChangeEvent{
-			Change: "focus",
-			Current: &I3MsgWorkspace{
-				Type:      "workspace",
-				Workspace: workspace,
-			},
-		}
+func (s *I3MsgServer) WorkspaceChanged(change string, current, old *Workspace) {
+	e := &WorkspaceChangeEvent{
+		Change: change,
 	}
+	if current != nil {
+		e.Current = &I3MsgWorkspace{Type: "workspace", Workspace: current}
+	}
+	if old != nil {
+		e.Old = &I3MsgWorkspace{Type: "workspace", Workspace: old}
+	}
+	s.events.Publish(EventWorkspace, e)
 }
 
-func (s *I3MsgServer) removeModeChangeEvents(events chan any) {
-	s.modeChangeEventsMtx.Lock()
-	defer s.modeChangeEventsMtx.Unlock()
-	s.modeChangeEvents.Delete(events)
-}
-
-func (s *I3MsgServer) addModeChangeEvents(events chan any) {
-	s.modeChangeEventsMtx.Lock()
-	defer s.modeChangeEventsMtx.Unlock()
-	s.modeChangeEvents.Add(events)
+type WindowChangeEvent struct {
+	Change    string `json:"change"`
+	Container *Node  `json:"container"`
 }
 
-func (s *I3MsgServer) removeWorkspaceChangeEvents(events chan any) {
-	s.workspaceChangeEventsMtx.Lock()
-	defer s.workspaceChangeEventsMtx.Unlock()
-	s.workspaceChangeEvents.Delete(events)
+func (s *I3MsgServer) WindowChanged(change string, container *Node) {
+	s.events.Publish(EventWindow, &WindowChangeEvent{
+		Change:    change,
+		Container: container,
+	})
 }
 
-func (s *I3MsgServer) addWorkspaceChangeEvents(events chan any) {
-	s.workspaceChangeEventsMtx.Lock()
-	defer s.workspaceChangeEventsMtx.Unlock()
-	s.workspaceChangeEvents.Add(events)
+func (s *I3MsgServer) OutputChanged() {
+	s.events.Publish(EventOutput, map[string]string{"change": "unspecified"})
 }
 
-func sendError(w http.ResponseWriter, err error) {
-	jsonErr := json.NewEncoder(w).Encode([]*CommandResult{
-		{
+func (s *I3MsgServer) command(w *Writer, r *Request) error {
+	commands, err := i3parser.ParseCommandString(r.Message)
+	if err != nil {
+		return w.Encode([]*CommandResult{{
 			Success: false,
 			I3msgError: &I3msgError{
 				ParseError:   true,
 				ErrorMessage: err.Error(),
+				Input:        r.Message,
 			},
-		},
-	})
-	if jsonErr != nil {
-		log.Printf("i3-msg server: %v", jsonErr)
+		}})
 	}
-}
 
-func (s *I3MsgServer) command(w *json.Encoder, r *Request) error {
 	results := []*CommandResult{}
-
-	commands := badparser.SplitCommands(badparser.TokenizeLine(r.Message))
-	for _, command := range commands {
-		err := run.Command(command, s.changeMode, s.restart)
+	for _, err := range run.Commands(r.Context(), commands, s.changeMode, s.restart) {
 		var msgErr *I3msgError
 		if err != nil {
 			msgErr = &I3msgError{
-				ParseError:   true,
 				ErrorMessage: err.Error(),
 			}
 		}
@@ -256,6 +386,7 @@ func (s *I3MsgServer) command(w *json.Encoder, r *Request) error {
 		})
 
 	}
+	s.Refresh()
 	return w.Encode(results)
 }
 
@@ -277,13 +408,36 @@ type Workspace struct {
 	Urgent  bool   `json:"urgent"`
 }
 
-func (s *I3MsgServer) getWorkspaces(w *json.Encoder, r *Request) error {
-	spaces, err := yabai.QuerySpaces()
+func newWorkspace(s *yabai.Space, displays []*yabai.Display) *Workspace {
+	ws := &Workspace{
+		ID:      workspaceConID | int64(s.ID),
+		Num:     s.Index,
+		Name:    workspaceName(s),
+		Visible: s.IsVisible,
+		Focused: s.HasFocus,
+		Urgent:  false,
+	}
+	for _, d := range displays {
+		if d.Index == s.DisplayIndex {
+			ws.Rect = frameRect(d.Frame)
+			ws.Output = outputName(d)
+			break
+		}
+	}
+	return ws
+}
+
+func (s *I3MsgServer) getWorkspaces(w *Writer, r *Request) error {
+	y, err := di.Resolve[yabai.Client](r.Context())
+	if err != nil {
+		return err
+	}
+	spaces, err := y.QuerySpaces()
 	if err != nil {
 		// sendError(w, err)
 		return err
 	}
-	displays, err := yabai.QueryDisplays()
+	displays, err := y.QueryDisplays()
 	if err != nil {
 		// sendError(w, err)
 		return err
@@ -291,66 +445,47 @@ func (s *I3MsgServer) getWorkspaces(w *json.Encoder, r *Request) error {
 
 	workspaces := make([]*Workspace, len(spaces))
 	for i, s := range spaces {
-		var display *yabai.Display
-		for _, d := range displays {
-			if d.ID == s.DisplayIndex {
-				display = d
-				break
-			}
-		}
-		workspaces[i] = &Workspace{
-			ID:      int64(s.ID),
-			Num:     s.Index,
-			Name:    s.Label,
-			Visible: s.IsVisible,
-			Focused: s.HasFocus,
-			Rect: Rect{
-				X:      int(display.Frame.X),
-				Y:      int(display.Frame.Y),
-				Width:  int(display.Frame.Width),
-				Height: int(display.Frame.Height),
-			},
-			Output: fmt.Sprint(s.DisplayIndex),
-			Urgent: false,
-		}
+		workspaces[i] = newWorkspace(s, displays)
 	}
 
 	return w.Encode(workspaces)
 }
 
-func (s *I3MsgServer) subscribe(w *json.Encoder, r *Request) error {
-	events := []string{}
+// subscribe registers the connection for events. Events are written to the
+// connection as they are published, other requests can still be sent on the
+// same connection.
+func (s *I3MsgServer) subscribe(w *Writer, r *Request) error {
+	names := []string{}
 
-	err := json.Unmarshal([]byte(r.Message), &events)
+	err := json.Unmarshal([]byte(r.Message), &names)
 	if err != nil {
-		return fmt.Errorf("i3-msg: subscribe: %w", err)
-	}
-
-	eventChan := make(chan any)
-	for _, e := range events {
-		switch e {
-		case "mode":
-			s.addModeChangeEvents(eventChan)
-			defer s.removeModeChangeEvents(eventChan)
-		case "workspace":
-			s.addWorkspaceChangeEvents(eventChan)
-			defer s.removeWorkspaceChangeEvents(eventChan)
-		}
+		return w.Encode(map[string]bool{"success": false})
 	}
 
-	for {
-		log.Print("Listening for i3-msg events")
-		select {
-		case <-r.Context().Done():
-			return nil
-		case event := <-eventChan:
-			log.Printf("event %v", event)
-			err := w.Encode(event)
-			if err != nil {
-				return err
-			}
+	types := make([]MessageType, 0, len(names))
+	for _, name := range names {
+		t, ok := eventNames[name]
+		if !ok {
+			return w.Encode(map[string]bool{"success": false})
 		}
+		types = append(types, t)
+	}
+
+	// reply before subscribing so no event can reach the client first
+	err = w.Encode(map[string]bool{"success": true})
+	if err != nil {
+		return err
 	}
+
+	sub := s.events.Subscribe(types, func(e *Event) error {
+		return w.Event(e.Type, e.Payload)
+	})
+	r.conn.subscriptions = append(r.conn.subscriptions, sub)
+
+	if slices.Contains(types, EventTick) {
+		return w.Event(EventTick, &TickEvent{First: true, Payload: ""})
+	}
+	return nil
 }
 
 // "get_workspaces": // Gets the current workspaces. The reply will be a JSON-encoded list of workspaces.
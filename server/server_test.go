@@ -0,0 +1,395 @@
+package server
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"net"
+	"os"
+	"path"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/abibby/salusa/di"
+	"github.com/abibby/yabai3/config"
+	"github.com/abibby/yabai3/run"
+	"github.com/abibby/yabai3/yabai"
+	"github.com/stretchr/testify/assert"
+)
+
+func startTestServer(t *testing.T) (*I3MsgServer, *yabai.Fake) {
+	t.Setenv("I3SOCK", path.Join(t.TempDir(), "i3.socket"))
+
+	ctx := di.ContextWithDependencyProvider(
+		context.Background(),
+		di.NewDependencyProvider(),
+	)
+	f := yabai.NewFake()
+	f.AddDisplay(yabai.Frame{Width: 1000, Height: 800})
+	f.AddWindow(1, "kitty", "shell")
+	yabai.RegisterFake(ctx, f)
+	run.RegisterLayouts(ctx, run.NewLayouts())
+	run.RegisterOptions(ctx, run.NewOptions())
+	scratchpad, _ := run.NewScratchpad("")
+	run.RegisterScratchpad(ctx, scratchpad)
+	run.RegisterMarks(ctx, run.NewMarks())
+	run.RegisterWorkspaceHistory(ctx, run.NewWorkspaceHistory())
+	run.RegisterCreatedWorkspaces(ctx, run.NewCreatedWorkspaces())
+	processes := run.NewProcesses("")
+	run.RegisterProcesses(ctx, processes)
+	run.RegisterStartup(ctx, run.NewStartup("", processes))
+
+	ctx, cancel := context.WithCancel(ctx)
+	t.Cleanup(cancel)
+
+	s := New("1.2.3")
+	s.SetConfig(&config.Config{
+		Path: "/config",
+		Modes: []*config.Mode{
+			{Name: "resize"},
+			{Name: "default"},
+		},
+	})
+	err := s.Start(ctx, func(mode string) error { return nil }, func() error { return nil })
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s, f
+}
+
+func dial(t *testing.T) net.Conn {
+	c, err := net.Dial("unix", SocketPath())
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { c.Close() })
+	return c
+}
+
+func request(t *testing.T, c net.Conn, msgType MessageType, message string, v any) {
+	err := WriteMessage(c, msgType, []byte(message))
+	if err != nil {
+		t.Fatal(err)
+	}
+	replyType, payload, err := ReadMessage(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, msgType, replyType)
+	err = json.Unmarshal(payload, v)
+	if err != nil {
+		t.Fatal(err)
+	}
+}
+
+func TestServer_requests(t *testing.T) {
+	startTestServer(t)
+	c := dial(t)
+
+	version := &Version{}
+	request(t, c, MessageGetVersion, "", version)
+	assert.Equal(t, &Version{Major: 1, Minor: 2, Patch: 3, HumanReadable: "1.2.3", LoadedConfigFileName: "/config"}, version)
+
+	modes := []string{}
+	request(t, c, MessageGetBindingModes, "", &modes)
+	assert.Equal(t, []string{"default", "resize"}, modes)
+
+	workspaces := []*Workspace{}
+	request(t, c, MessageGetWorkspaces, "", &workspaces)
+	if assert.Len(t, workspaces, 1) {
+		assert.Equal(t, "1", workspaces[0].Name)
+		assert.True(t, workspaces[0].Focused)
+	}
+
+	outputs := []*Output{}
+	request(t, c, MessageGetOutputs, "", &outputs)
+	if assert.Len(t, outputs, 1) {
+		assert.Equal(t, "1", *outputs[0].CurrentWorkspace)
+	}
+
+	results := []*CommandResult{}
+	request(t, c, MessageRunCommand, "fullscreen toggle", &results)
+	if assert.Len(t, results, 1) {
+		assert.True(t, results[0].Success)
+	}
+
+	processes := []*run.Process{}
+	request(t, c, MessageGetProcesses, "", &processes)
+	assert.Empty(t, processes)
+
+	startup := &run.StartupStatus{}
+	request(t, c, MessageGetStartup, "", startup)
+	assert.False(t, startup.ExecRan)
+	assert.Empty(t, startup.Commands)
+
+	results = []*CommandResult{}
+	request(t, c, MessageRunCommand, "focus output up", &results)
+	if assert.Len(t, results, 1) {
+		assert.False(t, results[0].Success)
+		assert.False(t, results[0].ParseError)
+		assert.Equal(t, "focus output up: no output up", results[0].ErrorMessage)
+	}
+}
+
+func TestServer_unsupported(t *testing.T) {
+	startTestServer(t)
+	c := dial(t)
+
+	reply := map[string]any{}
+	request(t, c, MessageSync, "", &reply)
+	assert.Equal(t, false, reply["success"])
+	assert.Equal(t, "unsupported message type sync", reply["error"])
+
+	// the connection is still open
+	version := &Version{}
+	request(t, c, MessageGetVersion, "", version)
+	assert.Equal(t, "1.2.3", version.HumanReadable)
+}
+
+func TestServer_subscribe(t *testing.T) {
+	s, _ := startTestServer(t)
+	c := dial(t)
+
+	reply := map[string]bool{}
+	request(t, c, MessageSubscribe, `["mode"]`, &reply)
+	assert.True(t, reply["success"])
+
+	go s.ModeChanged("resize")
+
+	eventType, payload, err := ReadMessage(c)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, EventMode, eventType)
+	assert.JSONEq(t, `{"change":"resize","pango_markup":false}`, string(payload))
+}
+
+func TestServer_tick(t *testing.T) {
+	startTestServer(t)
+	events := dial(t)
+
+	reply := map[string]bool{}
+	request(t, events, MessageSubscribe, `["tick"]`, &reply)
+	assert.True(t, reply["success"])
+
+	eventType, payload, err := ReadMessage(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, EventTick, eventType)
+	assert.JSONEq(t, `{"first":true,"payload":""}`, string(payload))
+
+	c := dial(t)
+	request(t, c, MessageSendTick, "sync", &reply)
+	assert.True(t, reply["success"])
+
+	eventType, payload, err = ReadMessage(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	assert.Equal(t, EventTick, eventType)
+	assert.JSONEq(t, `{"first":false,"payload":"sync"}`, string(payload))
+}
+
+func TestServer_stalledSubscriber(t *testing.T) {
+	s, _ := startTestServer(t)
+	events := dial(t)
+
+	reply := map[string]bool{}
+	request(t, events, MessageSubscribe, `["binding"]`, &reply)
+	assert.True(t, reply["success"])
+
+	// events isn't read from again, the socket buffer fills up and the
+	// subscriber is dropped instead of blocking the binding
+	done := make(chan struct{})
+	go func() {
+		s.BindingTriggered(strings.Repeat("x", 8<<20), "a", nil)
+		s.BindingTriggered("focus left", "a", nil)
+		close(done)
+	}()
+	select {
+	case <-done:
+	case <-time.After(5 * time.Second):
+		t.Fatal("publishing blocked on a subscriber that isn't reading")
+	}
+}
+
+func TestServer_subscribeInvalid(t *testing.T) {
+	startTestServer(t)
+	c := dial(t)
+
+	reply := map[string]bool{}
+	request(t, c, MessageSubscribe, `["mode","nope"]`, &reply)
+	assert.False(t, reply["success"])
+}
+
+func TestServer_signalEvents(t *testing.T) {
+	_, f := startTestServer(t)
+	f.AddSpace(1)
+	events := dial(t)
+
+	reply := map[string]bool{}
+	request(t, events, MessageSubscribe, `["workspace","window"]`, &reply)
+
+	c := dial(t)
+	results := []*CommandResult{}
+	request(t, c, MessageRunCommand, "workspace 2", &results)
+
+	expected := []struct {
+		eventType MessageType
+		change    string
+	}{
+		{EventWorkspace, "init"},
+		{EventWorkspace, "focus"},
+	}
+	for _, e := range expected {
+		eventType, payload, err := ReadMessage(events)
+		if err != nil {
+			t.Fatal(err)
+		}
+		event := &WorkspaceChangeEvent{}
+		assert.NoError(t, json.Unmarshal(payload, event))
+		assert.Equal(t, e.eventType, eventType)
+		assert.Equal(t, e.change, event.Change)
+		if e.change == "focus" {
+			assert.Equal(t, "2", event.Current.Name)
+			assert.Equal(t, "1", event.Old.Name)
+		}
+	}
+
+	f.AddWindow(2, "Notes", "todo")
+	request(t, c, MessageYabaiSignal, `{"event":"window_created"}`, &reply)
+	eventType, payload, err := ReadMessage(events)
+	if err != nil {
+		t.Fatal(err)
+	}
+	event := &WindowChangeEvent{}
+	assert.NoError(t, json.Unmarshal(payload, event))
+	assert.Equal(t, EventWindow, eventType)
+	assert.Equal(t, "new", event.Change)
+	assert.Equal(t, "Notes", event.Container.WindowProperties.Class)
+}
+
+func TestServer_signals(t *testing.T) {
+	s, f := startTestServer(t)
+	assert.Contains(t, f.Signals(), "yabai3_space_changed")
+
+	assert.NoError(t, s.Close())
+	assert.Empty(t, f.Signals())
+}
+
+func TestServer_removeSignals(t *testing.T) {
+	s, f := startTestServer(t)
+	assert.NoError(t, f.Yabai("signal", "--remove", "yabai3_space_changed"))
+
+	// the missing signal is reported and every other one is still removed
+	err := s.Close()
+	assert.ErrorContains(t, err, "failed to remove yabai signal space_changed")
+	assert.Empty(t, f.Signals())
+}
+
+func TestServer_forWindow(t *testing.T) {
+	s, f := startTestServer(t)
+	file := path.Join(t.TempDir(), "config")
+	err := os.WriteFile(file, []byte(`for_window [class="Notes"] floating enable
+for_window [title="^todo$"] fullscreen toggle
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(file)
+	if err != nil {
+		t.Fatal(err)
+	}
+	s.SetConfig(cfg)
+
+	c := dial(t)
+	reply := map[string]bool{}
+
+	w := f.AddWindow(1, "Notes", "todo")
+	message := fmt.Sprintf(`{"event":"window_created","env":{"YABAI_WINDOW_ID":"%d"}}`, w.ID)
+	request(t, c, MessageYabaiSignal, message, &reply)
+	// a second signal for the same window doesn't run the rules again
+	request(t, c, MessageYabaiSignal, strings.Replace(message, "created", "title_changed", 1), &reply)
+
+	windows, err := f.QueryWindows()
+	assert.NoError(t, err)
+	for _, window := range windows {
+		if window.ID == w.ID {
+			// the floating rule is installed as a yabai rule when the config
+			// is applied so only fullscreen runs here
+			assert.False(t, window.IsFloating)
+			assert.True(t, window.HasFullscreenZoom)
+		}
+	}
+}
+
+func TestServer_layout(t *testing.T) {
+	startTestServer(t)
+	c := dial(t)
+
+	results := []*CommandResult{}
+	request(t, c, MessageRunCommand, "layout tabbed", &results)
+	if assert.Len(t, results, 1) {
+		assert.True(t, results[0].Success)
+	}
+
+	root := &Node{}
+	request(t, c, MessageGetTree, "", root)
+	workspace := root.Nodes[0].Nodes[0].Nodes[0]
+	assert.Equal(t, "workspace", workspace.Type)
+	assert.Equal(t, "tabbed", workspace.Layout)
+}
+
+func TestServer_marks(t *testing.T) {
+	s, f := startTestServer(t)
+	c := dial(t)
+
+	results := []*CommandResult{}
+	request(t, c, MessageRunCommand, "mark a; mark --add b", &results)
+	if assert.Len(t, results, 2) {
+		assert.True(t, results[0].Success)
+		assert.True(t, results[1].Success)
+	}
+
+	marks := []string{}
+	request(t, c, MessageGetMarks, "", &marks)
+	assert.Equal(t, []string{"a", "b"}, marks)
+
+	root := &Node{}
+	request(t, c, MessageGetTree, "", root)
+	window := root.Nodes[0].Nodes[0].Nodes[0].Nodes[0]
+	assert.Equal(t, []string{"a", "b"}, window.Marks)
+
+	w, err := f.QueryActiveWindow()
+	if !assert.NoError(t, err) {
+		return
+	}
+	s.forgetWindow(strconv.Itoa(w.ID))
+	request(t, c, MessageGetMarks, "", &marks)
+	assert.Equal(t, []string{}, marks)
+}
+
+func TestServer_collectWorkspaces(t *testing.T) {
+	_, f := startTestServer(t)
+	c := dial(t)
+
+	results := []*CommandResult{}
+	request(t, c, MessageRunCommand, "workspace notes; workspace 1", &results)
+	if assert.Len(t, results, 2) {
+		assert.True(t, results[0].Success)
+		assert.True(t, results[1].Success)
+	}
+	spaces, err := f.QuerySpaces()
+	assert.NoError(t, err)
+	assert.Len(t, spaces, 2)
+
+	reply := map[string]bool{}
+	request(t, c, MessageYabaiSignal, `{"event":"space_changed","env":{}}`, &reply)
+	spaces, err = f.QuerySpaces()
+	assert.NoError(t, err)
+	assert.Len(t, spaces, 1)
+}
@@ -0,0 +1,270 @@
+package server
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log"
+	"os"
+	"strings"
+
+	"github.com/abibby/yabai3/run"
+	"github.com/abibby/yabai3/yabai"
+)
+
+// signals are the yabai events that can change what i3 clients see. Every one
+// of them calls back into the daemon with `yabai3 signal <event>`.
+var signals = []string{
+	"space_changed",
+	"space_created",
+	"space_destroyed",
+	"display_changed",
+	"display_added",
+	"display_removed",
+	"display_moved",
+	"window_focused",
+	"window_created",
+	"window_destroyed",
+	"window_moved",
+	"window_resized",
+	"window_minimized",
+	"window_deminimized",
+	"window_title_changed",
+	"application_front_switched",
+}
+
+const signalLabelPrefix = "yabai3_"
+
+// Signal is the payload of a yabai_signal message.
+type Signal struct {
+	Event string            `json:"event"`
+	Env   map[string]string `json:"env"`
+}
+
+// NewSignal builds the signal for event from the YABAI_* variables yabai sets
+// when running a signal action.
+func NewSignal(event string) *Signal {
+	env := map[string]string{}
+	for _, e := range os.Environ() {
+		k, v, _ := strings.Cut(e, "=")
+		if strings.HasPrefix(k, "YABAI_") {
+			env[k] = v
+		}
+	}
+	return &Signal{
+		Event: event,
+		Env:   env,
+	}
+}
+
+func shellQuote(s string) string {
+	return "'" + strings.ReplaceAll(s, "'", `'\''`) + "'"
+}
+
+func (s *I3MsgServer) addSignals() error {
+	exe, err := os.Executable()
+	if err != nil {
+		return err
+	}
+	for _, event := range signals {
+		action := fmt.Sprintf("I3SOCK=%s %s signal %s", shellQuote(s.socketPath), shellQuote(exe), event)
+		err := s.yabai.Yabai("signal", "--add", "event="+event, "label="+signalLabelPrefix+event, "action="+action)
+		if err != nil {
+			return fmt.Errorf("failed to add yabai signal %s: %w", event, err)
+		}
+	}
+	return nil
+}
+
+func (s *I3MsgServer) removeSignals() error {
+	// keep going after a failure, a signal left behind keeps running
+	// `yabai3 signal` after yabai3 has stopped
+	errs := []error{}
+	for _, event := range signals {
+		err := s.yabai.Yabai("signal", "--remove", signalLabelPrefix+event)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("failed to remove yabai signal %s: %w", event, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+func (s *I3MsgServer) yabaiSignal(w *Writer, r *Request) error {
+	signal := &Signal{}
+	err := json.Unmarshal([]byte(r.Message), signal)
+	if err != nil {
+		return w.Encode(map[string]bool{"success": false})
+	}
+	switch signal.Event {
+	case "window_created", "window_title_changed":
+		s.applyWindowRules(r.Context(), signal.Env["YABAI_WINDOW_ID"])
+	case "window_destroyed":
+		s.forgetWindow(signal.Env["YABAI_WINDOW_ID"])
+	}
+	switch signal.Event {
+	case "space_changed", "window_destroyed", "window_moved":
+		err = run.CollectWorkspaces(s.yabai, s.created, s.options)
+		if err != nil {
+			log.Print(err)
+		}
+	}
+	s.Refresh()
+	return w.Encode(map[string]bool{"success": true})
+}
+
+type state struct {
+	displays []*yabai.Display
+	spaces   []*yabai.Space
+	windows  []*yabai.Window
+}
+
+// Refresh queries yabai and sends workspace, window and output events for
+// everything that changed since the last refresh.
+func (s *I3MsgServer) Refresh() {
+	s.stateMtx.Lock()
+	defer s.stateMtx.Unlock()
+
+	if s.yabai == nil {
+		return
+	}
+
+	displays, err := s.yabai.QueryDisplays()
+	if err != nil {
+		log.Printf("i3-msg server: refresh: %v", err)
+		return
+	}
+	spaces, err := s.yabai.QuerySpaces()
+	if err != nil {
+		log.Printf("i3-msg server: refresh: %v", err)
+		return
+	}
+	windows, err := s.yabai.QueryWindows()
+	if err != nil {
+		log.Printf("i3-msg server: refresh: %v", err)
+		return
+	}
+	next := &state{
+		displays: displays,
+		spaces:   spaces,
+		windows:  windows,
+	}
+
+	prev := s.state
+	s.state = next
+	if prev == nil {
+		return
+	}
+
+	s.diffOutputs(prev, next)
+	s.diffWorkspaces(prev, next)
+	s.diffWindows(prev, next)
+}
+
+func (s *I3MsgServer) diffOutputs(prev, next *state) {
+	if len(prev.displays) != len(next.displays) {
+		s.OutputChanged()
+		return
+	}
+	for i, d := range next.displays {
+		p := prev.displays[i]
+		if p.ID != d.ID || frameRect(p.Frame) != frameRect(d.Frame) {
+			s.OutputChanged()
+			return
+		}
+	}
+}
+
+func (s *I3MsgServer) diffWorkspaces(prev, next *state) {
+	prevSpaces := map[int]*yabai.Space{}
+	for _, sp := range prev.spaces {
+		prevSpaces[sp.ID] = sp
+	}
+	nextSpaces := map[int]*yabai.Space{}
+	for _, sp := range next.spaces {
+		nextSpaces[sp.ID] = sp
+	}
+
+	for _, sp := range next.spaces {
+		p, ok := prevSpaces[sp.ID]
+		if !ok {
+			s.WorkspaceChanged("init", newWorkspace(sp, next.displays), nil)
+		} else if p.DisplayIndex != sp.DisplayIndex {
+			s.WorkspaceChanged("move", newWorkspace(sp, next.displays), nil)
+		}
+	}
+
+	var prevFocused, nextFocused *yabai.Space
+	for _, sp := range prev.spaces {
+		if sp.HasFocus {
+			prevFocused = sp
+		}
+	}
+	for _, sp := range next.spaces {
+		if sp.HasFocus {
+			nextFocused = sp
+		}
+	}
+	if nextFocused != nil && (prevFocused == nil || prevFocused.ID != nextFocused.ID) {
+		var old *Workspace
+		if prevFocused != nil {
+			if sp, ok := nextSpaces[prevFocused.ID]; ok {
+				old = newWorkspace(sp, next.displays)
+			} else {
+				old = newWorkspace(prevFocused, prev.displays)
+			}
+			// workspaces focused outside of yabai3 count for back_and_forth
+			s.history.Focus(workspaceName(prevFocused))
+		}
+		s.history.Focus(workspaceName(nextFocused))
+		s.WorkspaceChanged("focus", newWorkspace(nextFocused, next.displays), old)
+	}
+
+	for _, sp := range prev.spaces {
+		if _, ok := nextSpaces[sp.ID]; !ok {
+			s.WorkspaceChanged("empty", newWorkspace(sp, prev.displays), nil)
+		}
+	}
+}
+
+func (s *I3MsgServer) diffWindows(prev, next *state) {
+	prevWindows := map[int]*yabai.Window{}
+	for _, w := range prev.windows {
+		prevWindows[w.ID] = w
+	}
+	nextWindows := map[int]*yabai.Window{}
+	for _, w := range next.windows {
+		nextWindows[w.ID] = w
+	}
+
+	for _, w := range prev.windows {
+		if _, ok := nextWindows[w.ID]; !ok {
+			s.WindowChanged("close", windowNode(w))
+		}
+	}
+
+	for _, w := range next.windows {
+		p, ok := prevWindows[w.ID]
+		if !ok {
+			s.WindowChanged("new", windowNode(w))
+			if w.HasFocus {
+				s.WindowChanged("focus", windowNode(w))
+			}
+			continue
+		}
+		if w.HasFocus && !p.HasFocus {
+			s.WindowChanged("focus", windowNode(w))
+		}
+		if w.Title != p.Title {
+			s.WindowChanged("title", windowNode(w))
+		}
+		if w.Space != p.Space {
+			s.WindowChanged("move", windowNode(w))
+		}
+		if w.IsFloating != p.IsFloating {
+			s.WindowChanged("floating", windowNode(w))
+		}
+		if w.HasFullscreenZoom != p.HasFullscreenZoom || w.IsNativeFullscreen != p.IsNativeFullscreen {
+			s.WindowChanged("fullscreen_mode", windowNode(w))
+		}
+	}
+}
@@ -0,0 +1,389 @@
+package server
+
+import (
+	"fmt"
+	"slices"
+
+	"github.com/abibby/salusa/di"
+	"github.com/abibby/yabai3/run"
+	"github.com/abibby/yabai3/yabai"
+)
+
+// Con ids for everything that isn't a window are offset so they can't collide
+// with yabai window ids, which are used as the con id of each window.
+const (
+	rootConID      = int64(1) << 40
+	outputConID    = int64(2) << 40
+	contentConID   = int64(3) << 40
+	workspaceConID = int64(4) << 40
+	splitConID     = int64(5) << 40
+)
+
+type WindowProperties struct {
+	Class      string `json:"class"`
+	Instance   string `json:"instance"`
+	Title      string `json:"title"`
+	WindowRole string `json:"window_role"`
+}
+
+// Node is a container in the i3 layout tree.
+// https://i3wm.org/docs/ipc.html#_tree_reply
+type Node struct {
+	ID                 int64             `json:"id"`
+	Name               string            `json:"name"`
+	Type               string            `json:"type"`
+	Border             string            `json:"border"`
+	CurrentBorderWidth int               `json:"current_border_width"`
+	Layout             string            `json:"layout"`
+	Orientation        string            `json:"orientation"`
+	Percent            *float64          `json:"percent"`
+	Rect               Rect              `json:"rect"`
+	WindowRect         Rect              `json:"window_rect"`
+	DecoRect           Rect              `json:"deco_rect"`
+	Geometry           Rect              `json:"geometry"`
+	Window             *int              `json:"window"`
+	WindowProperties   *WindowProperties `json:"window_properties,omitempty"`
+	WindowType         *string           `json:"window_type"`
+	Urgent             bool              `json:"urgent"`
+	Marks              []string          `json:"marks"`
+	Focused            bool              `json:"focused"`
+	Focus              []int64           `json:"focus"`
+	FullscreenMode     int               `json:"fullscreen_mode"`
+	Floating           string            `json:"floating,omitempty"`
+	Sticky             bool              `json:"sticky"`
+	Num                *int              `json:"num,omitempty"`
+	Output             string            `json:"output,omitempty"`
+	Nodes              []*Node           `json:"nodes"`
+	FloatingNodes      []*Node           `json:"floating_nodes"`
+}
+
+func newNode(id int64, nodeType, name string, rect Rect) *Node {
+	return &Node{
+		ID:            id,
+		Name:          name,
+		Type:          nodeType,
+		Border:        "none",
+		Layout:        "splith",
+		Orientation:   "none",
+		Rect:          rect,
+		Marks:         []string{},
+		Focus:         []int64{},
+		Nodes:         []*Node{},
+		FloatingNodes: []*Node{},
+	}
+}
+
+// focusedChild reports whether n is focused or contains the focused con.
+func (n *Node) focusedChild() bool {
+	if n.Focused {
+		return true
+	}
+	for _, c := range n.Nodes {
+		if c.focusedChild() {
+			return true
+		}
+	}
+	for _, c := range n.FloatingNodes {
+		if c.focusedChild() {
+			return true
+		}
+	}
+	return false
+}
+
+// setFocus fills the focus stack with the focused child first.
+func (n *Node) setFocus() {
+	children := append(slices.Clone(n.Nodes), n.FloatingNodes...)
+	slices.SortStableFunc(children, func(a, b *Node) int {
+		if a.focusedChild() == b.focusedChild() {
+			return 0
+		} else if a.focusedChild() {
+			return -1
+		}
+		return 1
+	})
+	n.Focus = make([]int64, len(children))
+	for i, c := range children {
+		n.Focus[i] = c.ID
+	}
+}
+
+func frameRect(f *yabai.Frame) Rect {
+	if f == nil {
+		return Rect{}
+	}
+	return Rect{
+		X:      int(f.X),
+		Y:      int(f.Y),
+		Width:  int(f.Width),
+		Height: int(f.Height),
+	}
+}
+
+func outputName(d *yabai.Display) string {
+	return run.OutputName(d)
+}
+
+func workspaceName(s *yabai.Space) string {
+	if s.Label != "" {
+		return s.Label
+	}
+	return fmt.Sprint(s.Index)
+}
+
+// setMarks fills in the marks of every window below n.
+func (n *Node) setMarks(marks *run.Marks) {
+	if n.Window != nil {
+		n.Marks = marks.Window(*n.Window)
+	}
+	for _, c := range n.Nodes {
+		c.setMarks(marks)
+	}
+	for _, c := range n.FloatingNodes {
+		c.setMarks(marks)
+	}
+}
+
+// BuildTree assembles the i3 layout tree root → output → content → workspace
+// → con from yabai's displays, spaces and windows. layouts tells stacked and
+// tabbed spaces apart and gives the split direction of workspaces yabai
+// hasn't split yet.
+func BuildTree(displays []*yabai.Display, spaces []*yabai.Space, windows []*yabai.Window, layouts *run.Layouts) *Node {
+	root := newNode(rootConID, "root", "root", Rect{})
+	splitID := splitConID
+
+	for _, d := range displays {
+		rect := frameRect(d.Frame)
+		output := newNode(outputConID|int64(d.ID), "output", outputName(d), rect)
+		output.Layout = "output"
+		content := newNode(contentConID|int64(d.ID), "con", "content", rect)
+
+		for _, s := range spaces {
+			if s.DisplayIndex != d.Index {
+				continue
+			}
+			num := s.Index
+			ws := newNode(workspaceConID|int64(s.ID), "workspace", workspaceName(s), rect)
+			ws.Num = &num
+			ws.Output = output.Name
+
+			tiled := []*yabai.Window{}
+			for _, w := range windows {
+				if w.Space != s.Index || w.IsMinimized || w.IsHidden {
+					continue
+				}
+				if w.IsFloating || s.Type == "float" {
+					con := newNode(splitID, "floating_con", "", frameRect(w.Frame))
+					splitID++
+					con.Floating = "user_on"
+					con.Nodes = []*Node{windowNode(w)}
+					con.setFocus()
+					ws.FloatingNodes = append(ws.FloatingNodes, con)
+					continue
+				}
+				tiled = append(tiled, w)
+			}
+
+			ws.Layout = layouts.Get(s)
+			if s.Type == "stack" {
+				for _, w := range tiled {
+					ws.Nodes = append(ws.Nodes, windowNode(w))
+				}
+				setPercent(ws)
+			} else if len(tiled) > 0 {
+				split := splitWindows(tiled, &splitID)
+				if split.Window == nil {
+					ws.Layout = split.Layout
+					ws.Orientation = split.Orientation
+					ws.Nodes = split.Nodes
+					setPercent(ws)
+				} else {
+					ws.Nodes = []*Node{split}
+				}
+			}
+			ws.setFocus()
+			content.Nodes = append(content.Nodes, ws)
+		}
+		content.setFocus()
+		output.Nodes = []*Node{content}
+		output.setFocus()
+		root.Nodes = append(root.Nodes, output)
+	}
+	root.setFocus()
+	return root
+}
+
+func windowNode(w *yabai.Window) *Node {
+	id := w.ID
+	windowType := "normal"
+	switch w.Subrole {
+	case "AXDialog", "AXSystemDialog":
+		windowType = "dialog"
+	case "AXFloatingWindow", "AXSystemFloatingWindow":
+		windowType = "utility"
+	}
+	n := newNode(int64(w.ID), "con", w.Title, frameRect(w.Frame))
+	n.Border = "normal"
+	n.Window = &id
+	n.WindowType = &windowType
+	n.WindowRect = Rect{Width: n.Rect.Width, Height: n.Rect.Height}
+	n.Geometry = n.WindowRect
+	n.WindowProperties = &WindowProperties{
+		Class:      w.App,
+		Instance:   w.App,
+		Title:      w.Title,
+		WindowRole: w.Role,
+	}
+	n.Focused = w.HasFocus
+	n.Sticky = w.IsSticky
+	n.Floating = "auto_off"
+	if w.IsFloating {
+		n.Floating = "user_on"
+	}
+	if w.HasFullscreenZoom || w.IsNativeFullscreen {
+		n.FullscreenMode = 1
+	}
+	return n
+}
+
+// splitWindows rebuilds the bsp tree yabai keeps for a space. yabai only
+// reports the orientation of each window's parent (split-type), so the tree is
+// recovered by cutting the windows along edges that no window crosses,
+// preferring the orientation yabai reports for the windows being split. Nested
+// splits with the same orientation are flattened into a single con the way i3
+// does.
+func splitWindows(windows []*yabai.Window, nextID *int64) *Node {
+	if len(windows) == 1 {
+		return windowNode(windows[0])
+	}
+
+	horizontal := cut(windows, func(f *yabai.Frame) (float32, float32) { return f.X, f.X + f.Width })
+	vertical := cut(windows, func(f *yabai.Frame) (float32, float32) { return f.Y, f.Y + f.Height })
+
+	groups, layout := horizontal, "splith"
+	if len(horizontal) < 2 || (len(vertical) >= 2 && preferVertical(windows)) {
+		groups, layout = vertical, "splitv"
+	}
+	if len(groups) < 2 {
+		// Overlapping windows can't be cut, treat them as a stack.
+		groups, layout = [][]*yabai.Window{}, "stacked"
+		for _, w := range windows {
+			groups = append(groups, []*yabai.Window{w})
+		}
+	}
+
+	n := newNode(*nextID, "con", "", boundingRect(windows))
+	*nextID++
+	n.Layout = layout
+	n.Orientation = "horizontal"
+	if layout == "splitv" {
+		n.Orientation = "vertical"
+	}
+	for _, g := range groups {
+		child := splitWindows(g, nextID)
+		if child.Window == nil && child.Layout == layout {
+			n.Nodes = append(n.Nodes, child.Nodes...)
+		} else {
+			n.Nodes = append(n.Nodes, child)
+		}
+	}
+	setPercent(n)
+	n.setFocus()
+	return n
+}
+
+// cut splits windows into groups separated by lines no window crosses along
+// the axis returned by span.
+func cut(windows []*yabai.Window, span func(f *yabai.Frame) (float32, float32)) [][]*yabai.Window {
+	sorted := slices.Clone(windows)
+	slices.SortStableFunc(sorted, func(a, b *yabai.Window) int {
+		aStart, _ := span(a.Frame)
+		bStart, _ := span(b.Frame)
+		if aStart < bStart {
+			return -1
+		} else if aStart > bStart {
+			return 1
+		}
+		return 0
+	})
+
+	groups := [][]*yabai.Window{}
+	var end float32
+	for i, w := range sorted {
+		start, wEnd := span(w.Frame)
+		if i == 0 || start >= end {
+			groups = append(groups, []*yabai.Window{})
+		}
+		groups[len(groups)-1] = append(groups[len(groups)-1], w)
+		if i == 0 || wEnd > end {
+			end = wEnd
+		}
+	}
+	return groups
+}
+
+// preferVertical reports whether yabai says most of the windows hang off
+// horizontal splits, one window above the other.
+func preferVertical(windows []*yabai.Window) bool {
+	count := 0
+	for _, w := range windows {
+		switch w.SplitType {
+		case "horizontal":
+			count++
+		case "vertical":
+			count--
+		}
+	}
+	return count > 0
+}
+
+func boundingRect(windows []*yabai.Window) Rect {
+	r := frameRect(windows[0].Frame)
+	right, bottom := r.X+r.Width, r.Y+r.Height
+	for _, w := range windows[1:] {
+		wr := frameRect(w.Frame)
+		r.X = min(r.X, wr.X)
+		r.Y = min(r.Y, wr.Y)
+		right = max(right, wr.X+wr.Width)
+		bottom = max(bottom, wr.Y+wr.Height)
+	}
+	r.Width = right - r.X
+	r.Height = bottom - r.Y
+	return r
+}
+
+// setPercent sets how much of the parent each child takes up along the
+// parent's split.
+func setPercent(parent *Node) {
+	for _, n := range parent.Nodes {
+		percent := 1 / float64(len(parent.Nodes))
+		if parent.Layout == "splith" && parent.Rect.Width > 0 {
+			percent = float64(n.Rect.Width) / float64(parent.Rect.Width)
+		} else if parent.Layout == "splitv" && parent.Rect.Height > 0 {
+			percent = float64(n.Rect.Height) / float64(parent.Rect.Height)
+		}
+		n.Percent = &percent
+	}
+}
+
+func (s *I3MsgServer) getTree(w *Writer, r *Request) error {
+	y, err := di.Resolve[yabai.Client](r.Context())
+	if err != nil {
+		return err
+	}
+	displays, err := y.QueryDisplays()
+	if err != nil {
+		return err
+	}
+	spaces, err := y.QuerySpaces()
+	if err != nil {
+		return err
+	}
+	windows, err := y.QueryWindows()
+	if err != nil {
+		return err
+	}
+	root := BuildTree(displays, spaces, windows, s.layouts)
+	root.setMarks(s.marks)
+	return w.Encode(root)
+}
@@ -0,0 +1,69 @@
+package server
+
+import (
+	"testing"
+
+	"github.com/abibby/yabai3/yabai"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestBuildTree(t *testing.T) {
+	displays := []*yabai.Display{
+		{ID: 1, Index: 1, Frame: &yabai.Frame{Width: 1000, Height: 800}, SpaceIndexes: []int{1, 2}},
+	}
+	spaces := []*yabai.Space{
+		{ID: 10, Index: 1, Label: "web", Type: "bsp", DisplayIndex: 1, HasFocus: true, IsVisible: true},
+		{ID: 11, Index: 2, Type: "stack", DisplayIndex: 1},
+	}
+	windows := []*yabai.Window{
+		{ID: 100, App: "Firefox", Title: "a", Space: 1, SplitType: "vertical", Frame: &yabai.Frame{X: 0, Y: 0, Width: 500, Height: 800}},
+		{ID: 101, App: "kitty", Title: "b", Space: 1, SplitType: "horizontal", HasFocus: true, Frame: &yabai.Frame{X: 500, Y: 0, Width: 500, Height: 400}},
+		{ID: 102, App: "kitty", Title: "c", Space: 1, SplitType: "horizontal", Frame: &yabai.Frame{X: 500, Y: 400, Width: 500, Height: 400}},
+		{ID: 103, App: "Calculator", Title: "d", Space: 1, IsFloating: true, Frame: &yabai.Frame{X: 100, Y: 100, Width: 200, Height: 300}},
+		{ID: 104, App: "Mail", Title: "e", Space: 2, Frame: &yabai.Frame{Width: 1000, Height: 800}},
+		{ID: 105, App: "Notes", Title: "f", Space: 2, Frame: &yabai.Frame{Width: 1000, Height: 800}},
+	}
+
+	root := BuildTree(displays, spaces, windows, nil)
+
+	assert.Equal(t, "root", root.Type)
+	if !assert.Len(t, root.Nodes, 1) {
+		return
+	}
+	output := root.Nodes[0]
+	assert.Equal(t, "output", output.Type)
+	assert.Equal(t, "content", output.Nodes[0].Name)
+
+	workspaces := output.Nodes[0].Nodes
+	if !assert.Len(t, workspaces, 2) {
+		return
+	}
+
+	web := workspaces[0]
+	assert.Equal(t, "workspace", web.Type)
+	assert.Equal(t, "web", web.Name)
+	assert.Equal(t, "splith", web.Layout)
+	if assert.Len(t, web.Nodes, 2) {
+		assert.Equal(t, int64(100), web.Nodes[0].ID)
+		assert.Equal(t, "Firefox", web.Nodes[0].WindowProperties.Class)
+		assert.Equal(t, 0.5, *web.Nodes[0].Percent)
+
+		right := web.Nodes[1]
+		assert.Equal(t, "splitv", right.Layout)
+		if assert.Len(t, right.Nodes, 2) {
+			assert.Equal(t, int64(101), right.Nodes[0].ID)
+			assert.True(t, right.Nodes[0].Focused)
+			assert.Equal(t, int64(102), right.Nodes[1].ID)
+		}
+		assert.Equal(t, right.ID, web.Focus[0])
+	}
+	if assert.Len(t, web.FloatingNodes, 1) {
+		assert.Equal(t, "floating_con", web.FloatingNodes[0].Type)
+		assert.Equal(t, int64(103), web.FloatingNodes[0].Nodes[0].ID)
+	}
+
+	stack := workspaces[1]
+	assert.Equal(t, "2", stack.Name)
+	assert.Equal(t, "stacked", stack.Layout)
+	assert.Len(t, stack.Nodes, 2)
+}
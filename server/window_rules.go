@@ -0,0 +1,113 @@
+package server
+
+import (
+	"context"
+	"log"
+	"strconv"
+
+	"github.com/abibby/salusa/set"
+	"github.com/abibby/yabai3/i3parser"
+	"github.com/abibby/yabai3/parser"
+	"github.com/abibby/yabai3/run"
+	"github.com/abibby/yabai3/yabai"
+)
+
+// applyWindowRules runs the assign and for_window rules the window matches.
+// Like i3, each rule runs at most once per window even if a title change
+// makes it match again. Rules that can be expressed as yabai rules are added
+// by run.SetRules instead.
+func (s *I3MsgServer) applyWindowRules(ctx context.Context, windowID string) {
+	id, err := strconv.Atoi(windowID)
+	if err != nil {
+		return
+	}
+	windows, err := s.yabai.QueryWindows()
+	if err != nil {
+		log.Printf("i3-msg server: window rules: %v", err)
+		return
+	}
+	var w *yabai.Window
+	for _, window := range windows {
+		if window.ID == id {
+			w = window
+		}
+	}
+	if w == nil {
+		return
+	}
+
+	cfg := s.getConfigFile()
+	for _, rule := range cfg.Assigns {
+		if _, static := run.AssignRule(rule.Assign); static {
+			continue
+		}
+		if !s.matchRule(id, rule, rule.Conditions, w, rule.NodeError) {
+			continue
+		}
+		err := run.Assign(s.yabai, w, rule.Assign)
+		if err != nil {
+			log.Print(rule.NodeError(rule.Target, err))
+		}
+	}
+
+	for _, rule := range s.getConfigFile().ForWindows {
+		if _, static := run.StaticRule(rule.Conditions, rule.Commands); static {
+			continue
+		}
+		if !s.matchRule(id, rule, rule.Conditions, w, rule.NodeError) {
+			continue
+		}
+		for _, err := range run.WindowCommands(ctx, w, rule.Commands, s.changeMode, s.restart) {
+			if err != nil {
+				log.Print(rule.NodeError(rule.Commands, err))
+			}
+		}
+	}
+}
+
+// matchRule reports whether a rule that hasn't run for the window yet matches
+// it, marking it as run if it does.
+func (s *I3MsgServer) matchRule(windowID int, rule any, conditions *i3parser.Conditions, w *yabai.Window, nodeError func(parser.Node, error) error) bool {
+	if s.ruleRan(windowID, rule) {
+		return false
+	}
+	ok, err := run.MatchWindow(s.yabai, s.marks, conditions, w)
+	if err != nil {
+		log.Print(nodeError(conditions, err))
+		return false
+	}
+	if ok {
+		s.markRuleRan(windowID, rule)
+	}
+	return ok
+}
+
+func (s *I3MsgServer) ruleRan(windowID int, rule any) bool {
+	s.windowRulesMtx.Lock()
+	defer s.windowRulesMtx.Unlock()
+	ran, ok := s.windowRules[windowID]
+	return ok && ran.Has(rule)
+}
+
+func (s *I3MsgServer) markRuleRan(windowID int, rule any) {
+	s.windowRulesMtx.Lock()
+	defer s.windowRulesMtx.Unlock()
+	ran, ok := s.windowRules[windowID]
+	if !ok {
+		ran = set.New[any]()
+		s.windowRules[windowID] = ran
+	}
+	ran.Add(rule)
+}
+
+func (s *I3MsgServer) forgetWindow(windowID string) {
+	id, err := strconv.Atoi(windowID)
+	if err != nil {
+		return
+	}
+	s.marks.RemoveWindow(id)
+
+	s.windowRulesMtx.Lock()
+	defer s.windowRulesMtx.Unlock()
+	delete(s.windowRules, id)
+}
@@ -0,0 +1,37 @@
+package main
+
+import (
+	"encoding/json"
+	"log"
+	"net"
+
+	"github.com/abibby/yabai3/server"
+)
+
+// Signal is run by yabai for every signal registered by the daemon and
+// forwards the event to it.
+func Signal(args []string) {
+	if len(args) != 1 {
+		log.Fatal("usage: yabai3 signal <event>")
+	}
+
+	b, err := json.Marshal(server.NewSignal(args[0]))
+	if err != nil {
+		log.Fatal(err)
+	}
+
+	conn, err := net.Dial("unix", server.SocketPath())
+	if err != nil {
+		log.Fatal(err)
+	}
+	defer conn.Close()
+
+	err = server.WriteMessage(conn, server.MessageYabaiSignal, b)
+	if err != nil {
+		log.Fatal(err)
+	}
+	_, _, err = server.ReadMessage(conn)
+	if err != nil {
+		log.Fatal(err)
+	}
+}
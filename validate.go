@@ -0,0 +1,120 @@
+package main
+
+import (
+	"fmt"
+	"log"
+	"os"
+	"strconv"
+
+	"github.com/abibby/yabai3/config"
+	"github.com/abibby/yabai3/i3parser"
+	"github.com/abibby/yabai3/parser"
+	"github.com/abibby/yabai3/run"
+	"golang.design/x/hotkey"
+)
+
+// Validate checks a config without connecting to yabai, printing every
+// problem it finds.
+func Validate(args []string) {
+	var cfg *config.Config
+	var err error
+	switch len(args) {
+	case 0:
+		cfg, err = readConfig()
+	case 1:
+		cfg, err = config.Load(args[0])
+	default:
+		log.Fatal("usage: yabai3 validate [config]")
+	}
+	if err != nil {
+		fmt.Fprintln(os.Stderr, err)
+		os.Exit(1)
+	}
+
+	for _, warning := range configWarnings(cfg) {
+		fmt.Fprintf(os.Stderr, "warning: %v\n", warning)
+	}
+	errs := validateConfig(cfg)
+	for _, err := range errs {
+		fmt.Fprintln(os.Stderr, err)
+	}
+	if len(errs) > 0 {
+		os.Exit(1)
+	}
+	fmt.Printf("%s: ok\n", cfg.Path)
+}
+
+type keyCombo struct {
+	mods hotkey.Modifier
+	key  hotkey.Key
+}
+
+func validateConfig(cfg *config.Config) []error {
+	errs := []error{}
+	for _, mode := range cfg.Modes {
+		bound := map[keyCombo]*config.BindSym{}
+		for _, b := range mode.BindSyms {
+			mods, key, err := keys(b.Keys.Value)
+			if err != nil {
+				errs = append(errs, b.NodeError(b.Keys, err))
+			} else {
+				combo := keyCombo{key: key}
+				for _, m := range mods {
+					combo.mods |= m
+				}
+				if first, ok := bound[combo]; ok {
+					errs = append(errs, b.NodeError(b.Keys, fmt.Errorf("duplicate binding %s in mode %s, already bound as %s", b.Keys.Value, mode.Name, first.Keys.Value)))
+				} else {
+					bound[combo] = b
+				}
+			}
+			errs = append(errs, validateCommands(cfg, b.Commands, b.NodeError)...)
+		}
+	}
+	for _, w := range cfg.ForWindows {
+		errs = append(errs, validateCommands(cfg, w.Commands, w.NodeError)...)
+	}
+	return errs
+}
+
+// configWarnings finds problems that don't stop the config from loading.
+// Assigns to a workspace that isn't named in a workspace directive only work
+// if the name is a space index, assigns by number match any workspace that
+// starts with the number.
+func configWarnings(cfg *config.Config) []error {
+	warnings := append([]error{}, cfg.Warnings...)
+	for _, a := range cfg.Assigns {
+		if a.Output != nil || a.Number != nil {
+			continue
+		}
+		workspace := run.AssignWorkspace(a.Assign)
+		if _, err := strconv.Atoi(workspace); err == nil {
+			continue
+		}
+		defined := false
+		for _, w := range cfg.Workspaces {
+			if w.Name == workspace {
+				defined = true
+			}
+		}
+		if !defined {
+			warnings = append(warnings, a.NodeError(a.Target, fmt.Errorf("workspace %s is not defined", workspace)))
+		}
+	}
+	return warnings
+}
+
+func validateCommands(cfg *config.Config, commands *i3parser.Commands, nodeError func(parser.Node, error) error) []error {
+	errs := []error{}
+	for _, c := range commands.List() {
+		args := c.Args()
+		if !run.IsCommand(args[0]) {
+			errs = append(errs, nodeError(c, fmt.Errorf("unsupported command %s", args[0])))
+			continue
+		}
+		if args[0] == "mode" && len(args) > 1 && cfg.Mode(args[len(args)-1]) == nil {
+			errs = append(errs, nodeError(c, fmt.Errorf("no mode %s", args[len(args)-1])))
+		}
+	}
+	return errs
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"os"
+	"path"
+	"testing"
+
+	"github.com/abibby/yabai3/config"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateConfig(t *testing.T) {
+	file := path.Join(t.TempDir(), "config")
+	err := os.WriteFile(file, []byte(`bindsym Mod1+a focus left
+bindsym Mod1+hyper focus right
+bindsym mod1+A fullscreen toggle
+bindsym Mod1+b title_format %title
+bindsym Mod1+r mode "resize"
+bindsym Mod1+s mode "system"
+
+mode "resize" {
+	bindsym Mod1+a mode default
+}
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	errs := []string{}
+	for _, err := range validateConfig(cfg) {
+		errs = append(errs, err.Error())
+	}
+	assert.Equal(t, []string{
+		file + ":2:9: invalid key or modifier hyper in Mod1+hyper",
+		file + ":3:9: duplicate binding mod1+A in mode default, already bound as Mod1+a",
+		file + ":4:16: unsupported command title_format",
+		file + ":6:16: no mode system",
+	}, errs)
+}
+
+func TestConfigWarnings(t *testing.T) {
+	file := path.Join(t.TempDir(), "config")
+	err := os.WriteFile(file, []byte(`workspace web output left
+assign [class="Firefox"] web
+assign [class="Slack"] 8
+assign [class="Discord"] chat
+assign [class="Zoom"] output right
+`), 0o644)
+	if err != nil {
+		t.Fatal(err)
+	}
+	cfg, err := config.Load(file)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	warnings := []string{}
+	for _, err := range configWarnings(cfg) {
+		warnings = append(warnings, err.Error())
+	}
+	assert.Equal(t, []string{
+		file + ":4:26: workspace chat is not defined",
+	}, warnings)
+}
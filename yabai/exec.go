@@ -0,0 +1,44 @@
+package yabai
+
+import (
+	"context"
+	"encoding/json"
+	"os/exec"
+	"strings"
+
+	"github.com/abibby/salusa/di"
+)
+
+// ExecClient talks to yabai by running `yabai -m` for every message.
+type ExecClient struct {
+	queries
+}
+
+var _ Client = (*ExecClient)(nil)
+
+func NewExecClient() *ExecClient {
+	c := &ExecClient{}
+	c.queries = queries{message: c.message}
+	return c
+}
+
+func (c *ExecClient) message(v any, args ...string) error {
+	b, err := exec.Command("yabai", append([]string{"-m"}, args...)...).CombinedOutput()
+	if err != nil {
+		return &Error{
+			Args:    args,
+			Message: strings.TrimSpace(string(b)),
+		}
+	}
+	if v == nil {
+		return nil
+	}
+
+	return json.Unmarshal(b, v)
+}
+
+func RegisterExec(ctx context.Context) {
+	di.RegisterSingleton(ctx, func() Client {
+		return NewExecClient()
+	})
+}
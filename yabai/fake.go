@@ -0,0 +1,916 @@
+package yabai
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"slices"
+	"strconv"
+	"strings"
+	"sync"
+
+	"github.com/abibby/salusa/di"
+)
+
+// Fake is an in-memory window manager. It keeps its own displays, spaces and
+// windows and applies yabai messages to them the way yabai would, so the
+// command layer can be exercised without macOS.
+type Fake struct {
+	mtx *sync.Mutex
+
+	displays []*Display
+	spaces   []*Space
+	windows  []*Window
+
+	// spaceDisplay maps space id to display id and windowSpace maps window id
+	// to space id. Indexes are derived from these in sync.
+	spaceDisplay map[int]int
+	windowSpace  map[int]int
+	visible      map[int]int
+
+	focusedDisplay int
+	focusedWindow  int
+	nextID         int
+
+	config   map[string]string
+	signals  map[string][]string
+	rules    map[string][]string
+	messages [][]string
+}
+
+var _ Client = (*Fake)(nil)
+
+func NewFake() *Fake {
+	return &Fake{
+		mtx:          &sync.Mutex{},
+		displays:     []*Display{},
+		spaces:       []*Space{},
+		windows:      []*Window{},
+		spaceDisplay: map[int]int{},
+		windowSpace:  map[int]int{},
+		visible:      map[int]int{},
+		config:       map[string]string{},
+		signals:      map[string][]string{},
+		rules:        map[string][]string{},
+		messages:     [][]string{},
+		nextID:       1,
+	}
+}
+
+func RegisterFake(ctx context.Context, f *Fake) {
+	di.RegisterSingleton(ctx, func() Client {
+		return f
+	})
+}
+
+// AddDisplay adds a display with a single empty space.
+func (f *Fake) AddDisplay(frame Frame) *Display {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	d := &Display{
+		ID:    f.id(),
+		Frame: &frame,
+	}
+	d.UUID = fmt.Sprintf("%08X-0000-0000-0000-000000000000", d.ID)
+	f.displays = append(f.displays, d)
+	if f.focusedDisplay == 0 {
+		f.focusedDisplay = d.ID
+	}
+
+	s := f.addSpace(d.ID)
+	f.visible[d.ID] = s.ID
+
+	f.sync()
+	return d
+}
+
+// AddSpace adds an empty space to the display with the given index.
+func (f *Fake) AddSpace(displayIndex int) *Space {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	d := f.displayByIndex(displayIndex)
+	if d == nil {
+		panic(fmt.Sprintf("no display %d", displayIndex))
+	}
+	s := f.addSpace(d.ID)
+	f.sync()
+	return s
+}
+
+// AddWindow adds a tiled window to the space with the given index. The window
+// is focused if its space is focused and has no other focused window.
+func (f *Fake) AddWindow(spaceIndex int, app, title string) *Window {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	s := f.spaceBySelector(strconv.Itoa(spaceIndex))
+	if s == nil {
+		panic(fmt.Sprintf("no space %d", spaceIndex))
+	}
+	w := &Window{
+		ID:        f.id(),
+		PID:       1000 + len(f.windows),
+		App:       app,
+		Title:     title,
+		Frame:     &Frame{},
+		Role:      "AXWindow",
+		Subrole:   "AXStandardWindow",
+		CanMove:   true,
+		CanResize: true,
+	}
+	f.windows = append(f.windows, w)
+	f.windowSpace[w.ID] = s.ID
+	if s.HasFocus && f.focusedWindow == 0 {
+		f.focusedWindow = w.ID
+	}
+	f.retile(s.ID)
+	f.sync()
+	return w
+}
+
+// Config returns the last value set with `config <key> <value>`.
+func (f *Fake) Config(key string) string {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return f.config[key]
+}
+
+// Messages returns every message sent with Yabai in order.
+func (f *Fake) Messages() [][]string {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return slices.Clone(f.messages)
+}
+
+func (f *Fake) QuerySpaces() ([]*Space, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return clone(f.spaces)
+}
+
+func (f *Fake) QueryActiveSpace() (*Space, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	for _, s := range f.spaces {
+		if s.HasFocus {
+			return clone(s)
+		}
+	}
+	return nil, errors.New("could not retrieve space details")
+}
+
+func (f *Fake) QueryWindows() ([]*Window, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return clone(f.windows)
+}
+
+func (f *Fake) QueryActiveWindow() (*Window, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	w := f.window(f.focusedWindow)
+	if w == nil {
+		return nil, errors.New("could not retrieve window details")
+	}
+	return clone(w)
+}
+
+func (f *Fake) QueryDisplays() ([]*Display, error) {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	return clone(f.displays)
+}
+
+func (f *Fake) Yabai(args ...string) error {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+
+	f.messages = append(f.messages, slices.Clone(args))
+	if len(args) == 0 {
+		return errors.New("unknown domain ''")
+	}
+
+	var err error
+	switch args[0] {
+	case "window":
+		err = f.windowMessage(args[1:])
+	case "space":
+		err = f.spaceMessage(args[1:])
+	case "display":
+		err = f.displayMessage(args[1:])
+	case "config":
+		err = f.configMessage(args[1:])
+	case "signal":
+		err = f.signalMessage(args[1:])
+	case "rule":
+		err = f.ruleMessage(args[1:])
+	default:
+		err = fmt.Errorf("unknown domain '%s'", args[0])
+	}
+	f.sync()
+	return err
+}
+
+func (f *Fake) windowMessage(args []string) error {
+	sel, command, value := splitMessage(args)
+	var w *Window
+	if sel == "" {
+		w = f.window(f.focusedWindow)
+	} else if id, err := strconv.Atoi(sel); err == nil {
+		w = f.window(id)
+	} else {
+		w = f.neighbour(f.window(f.focusedWindow), sel)
+	}
+	if w == nil {
+		return errors.New("could not locate the selected window")
+	}
+
+	switch command {
+	case "--focus":
+		target := w
+		if value != "" {
+			target = f.windowBySelector(w, value)
+		}
+		if target == nil {
+			return fmt.Errorf("could not locate a %sward managed window", value)
+		}
+		f.focusWindow(target)
+		return nil
+	case "--swap":
+		target := f.windowBySelector(w, value)
+		if target == nil {
+			return fmt.Errorf("could not locate a %sward managed window", value)
+		}
+		f.swap(w, target)
+		return nil
+	case "--space":
+		s := f.spaceBySelector(value)
+		if s == nil {
+			return fmt.Errorf("could not locate space with label '%s'", value)
+		}
+		from := f.windowSpace[w.ID]
+		if from == s.ID {
+			return errors.New("window is already located on the given space")
+		}
+		f.windowSpace[w.ID] = s.ID
+		f.retile(from)
+		f.retile(s.ID)
+		if f.focusedWindow == w.ID {
+			f.focusedWindow = f.firstWindow(from)
+		}
+		return nil
+	case "--display":
+		index, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("value '%s' is not a valid option for DISPLAY_SEL", value)
+		}
+		d := f.displayByIndex(index)
+		if d == nil {
+			return errors.New("could not locate the selected display")
+		}
+		from := f.windowSpace[w.ID]
+		to := f.visible[d.ID]
+		if from == to {
+			return errors.New("window is already located on the given display")
+		}
+		f.windowSpace[w.ID] = to
+		f.retile(from)
+		f.retile(to)
+		if f.focusedWindow == w.ID {
+			f.focusedWindow = f.firstWindow(from)
+		}
+		return nil
+	case "--insert":
+		switch value {
+		case "north", "east", "south", "west", "stack":
+			return nil
+		}
+		return fmt.Errorf("value '%s' is not a valid option for DIR_SEL", value)
+	case "--close":
+		// only windows with a close button, AXWindows, can be closed
+		if w.Role != "AXWindow" {
+			return errors.New("could not close window")
+		}
+		spaceID := f.windowSpace[w.ID]
+		f.windows = slices.DeleteFunc(f.windows, func(o *Window) bool { return o.ID == w.ID })
+		delete(f.windowSpace, w.ID)
+		if f.focusedWindow == w.ID {
+			f.focusedWindow = f.firstWindow(spaceID)
+		}
+		f.retile(spaceID)
+		return nil
+	case "--minimize":
+		w.IsMinimized = true
+		if f.focusedWindow == w.ID {
+			f.focusedWindow = f.firstWindow(f.windowSpace[w.ID])
+		}
+		f.retile(f.windowSpace[w.ID])
+		return nil
+	case "--deminimize":
+		target := f.windowBySelector(w, value)
+		if target == nil {
+			return errors.New("could not locate the selected window")
+		}
+		target.IsMinimized = false
+		f.focusWindow(target)
+		f.retile(f.windowSpace[target.ID])
+		return nil
+	case "--move":
+		return f.move(w, value)
+	case "--resize":
+		return f.resize(w, value)
+	case "--toggle":
+		switch value {
+		case "zoom-fullscreen":
+			w.HasFullscreenZoom = !w.HasFullscreenZoom
+			return nil
+		case "split":
+			if w.SplitType == "vertical" {
+				w.SplitType = "horizontal"
+			} else {
+				w.SplitType = "vertical"
+			}
+			return nil
+		case "sticky":
+			w.IsSticky = !w.IsSticky
+			return nil
+		case "float":
+			w.IsFloating = !w.IsFloating
+			f.retile(f.windowSpace[w.ID])
+			return nil
+		}
+		return fmt.Errorf("unknown value '%s' given to command '--toggle' for domain 'window'", value)
+	}
+	return fmt.Errorf("unknown command '%s' for domain 'window'", command)
+}
+
+func (f *Fake) spaceMessage(args []string) error {
+	sel, command, value := splitMessage(args)
+	var s *Space
+	if sel == "" {
+		s = f.focusedSpace()
+	} else {
+		s = f.spaceBySelector(sel)
+	}
+	if s == nil {
+		return errors.New("could not locate the selected space")
+	}
+
+	switch command {
+	case "--focus":
+		target := f.spaceBySelector(value)
+		if target == nil {
+			return fmt.Errorf("could not locate space with label '%s'", value)
+		}
+		if target.HasFocus {
+			return errors.New("cannot focus an already focused space")
+		}
+		f.focusSpace(target.ID)
+		return nil
+	case "--label":
+		s.Label = value
+		return nil
+	case "--display":
+		index, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("value '%s' is not a valid option for DISPLAY_SEL", value)
+		}
+		d := f.displayByIndex(index)
+		if d == nil {
+			return errors.New("could not locate the selected display")
+		}
+		from := f.spaceDisplay[s.ID]
+		if from == d.ID {
+			return errors.New("acting space is already located on the given display")
+		}
+		var other *Space
+		for _, o := range f.spaces {
+			if o.ID != s.ID && f.spaceDisplay[o.ID] == from {
+				other = o
+				break
+			}
+		}
+		if other == nil {
+			return errors.New("acting space is the last user-space on the source display and cannot be moved")
+		}
+		focused := f.focusedDisplay == from && f.visible[from] == s.ID
+		if f.visible[from] == s.ID {
+			f.visible[from] = other.ID
+		}
+		f.spaceDisplay[s.ID] = d.ID
+		if focused {
+			f.focusSpace(s.ID)
+		}
+		return nil
+	case "--create":
+		displayID := f.spaceDisplay[s.ID]
+		if value != "" {
+			index, err := strconv.Atoi(value)
+			if err != nil {
+				return fmt.Errorf("value '%s' is not a valid option for DISPLAY_SEL", value)
+			}
+			d := f.displayByIndex(index)
+			if d == nil {
+				return errors.New("could not locate the selected display")
+			}
+			displayID = d.ID
+		}
+		f.addSpace(displayID)
+		return nil
+	case "--destroy":
+		displayID := f.spaceDisplay[s.ID]
+		var other *Space
+		for _, o := range f.spaces {
+			if o.ID != s.ID && f.spaceDisplay[o.ID] == displayID {
+				other = o
+				break
+			}
+		}
+		if other == nil {
+			return errors.New("can not destroy the last space on a display")
+		}
+		for _, w := range f.spaceWindows(s.ID) {
+			f.windowSpace[w.ID] = other.ID
+		}
+		if f.visible[displayID] == s.ID {
+			if f.focusedDisplay == displayID {
+				f.focusSpace(other.ID)
+			} else {
+				f.visible[displayID] = other.ID
+			}
+		}
+		f.spaces = slices.DeleteFunc(f.spaces, func(o *Space) bool { return o.ID == s.ID })
+		delete(f.spaceDisplay, s.ID)
+		return nil
+	case "--layout":
+		switch value {
+		case "bsp", "stack", "float":
+			s.Type = value
+			return nil
+		}
+		return fmt.Errorf("value '%s' is not a valid option for LAYOUT", value)
+	}
+	return fmt.Errorf("unknown command '%s' for domain 'space'", command)
+}
+
+func (f *Fake) displayMessage(args []string) error {
+	_, command, value := splitMessage(args)
+	switch command {
+	case "--focus":
+		index, err := strconv.Atoi(value)
+		if err != nil {
+			return fmt.Errorf("value '%s' is not a valid option for DISPLAY_SEL", value)
+		}
+		d := f.displayByIndex(index)
+		if d == nil {
+			return errors.New("could not locate the selected display")
+		}
+		if d.ID == f.focusedDisplay {
+			return errors.New("cannot focus an already focused display")
+		}
+		f.focusSpace(f.visible[d.ID])
+		return nil
+	}
+	return fmt.Errorf("unknown command '%s' for domain 'display'", command)
+}
+
+func (f *Fake) configMessage(args []string) error {
+	if len(args) != 2 {
+		return fmt.Errorf("invalid config message %v", args)
+	}
+	f.config[args[0]] = args[1]
+	return nil
+}
+
+func (f *Fake) signalMessage(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("invalid signal message %v", args)
+	}
+	switch args[0] {
+	case "--add":
+		label := ""
+		for _, a := range args[1:] {
+			if l, ok := strings.CutPrefix(a, "label="); ok {
+				label = l
+			}
+		}
+		f.signals[label] = slices.Clone(args[1:])
+		return nil
+	case "--remove":
+		if _, ok := f.signals[args[1]]; !ok {
+			return fmt.Errorf("signal with label '%s' not found", args[1])
+		}
+		delete(f.signals, args[1])
+		return nil
+	}
+	return fmt.Errorf("unknown command '%s' for domain 'signal'", args[0])
+}
+
+// Signals returns the arguments of every registered signal by label.
+func (f *Fake) Signals() map[string][]string {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	signals := make(map[string][]string, len(f.signals))
+	for k, v := range f.signals {
+		signals[k] = slices.Clone(v)
+	}
+	return signals
+}
+
+func (f *Fake) ruleMessage(args []string) error {
+	if len(args) < 2 {
+		return fmt.Errorf("invalid rule message %v", args)
+	}
+	switch args[0] {
+	case "--add":
+		label := ""
+		for _, a := range args[1:] {
+			if l, ok := strings.CutPrefix(a, "label="); ok {
+				label = l
+			}
+		}
+		f.rules[label] = slices.Clone(args[1:])
+		return nil
+	case "--remove":
+		if _, ok := f.rules[args[1]]; !ok {
+			return fmt.Errorf("rule with label '%s' not found", args[1])
+		}
+		delete(f.rules, args[1])
+		return nil
+	}
+	return fmt.Errorf("unknown command '%s' for domain 'rule'", args[0])
+}
+
+// Rules returns the arguments of every rule by label.
+func (f *Fake) Rules() map[string][]string {
+	f.mtx.Lock()
+	defer f.mtx.Unlock()
+	rules := make(map[string][]string, len(f.rules))
+	for k, v := range f.rules {
+		rules[k] = slices.Clone(v)
+	}
+	return rules
+}
+
+func splitMessage(args []string) (selector, command, value string) {
+	if len(args) > 0 && !strings.HasPrefix(args[0], "--") {
+		selector = args[0]
+		args = args[1:]
+	}
+	if len(args) > 0 {
+		command = args[0]
+	}
+	if len(args) > 1 {
+		value = args[1]
+	}
+	return selector, command, value
+}
+
+func (f *Fake) id() int {
+	id := f.nextID
+	f.nextID++
+	return id
+}
+
+func (f *Fake) addSpace(displayID int) *Space {
+	s := &Space{
+		ID:   f.id(),
+		Type: "bsp",
+	}
+	s.UUID = fmt.Sprintf("FAKE-SPACE-%d", s.ID)
+	f.spaces = append(f.spaces, s)
+	f.spaceDisplay[s.ID] = displayID
+	return s
+}
+
+func (f *Fake) window(id int) *Window {
+	for _, w := range f.windows {
+		if w.ID == id {
+			return w
+		}
+	}
+	return nil
+}
+
+func (f *Fake) space(id int) *Space {
+	for _, s := range f.spaces {
+		if s.ID == id {
+			return s
+		}
+	}
+	return nil
+}
+
+func (f *Fake) display(id int) *Display {
+	for _, d := range f.displays {
+		if d.ID == id {
+			return d
+		}
+	}
+	return nil
+}
+
+func (f *Fake) displayByIndex(index int) *Display {
+	if index < 1 || index > len(f.displays) {
+		return nil
+	}
+	return f.displays[index-1]
+}
+
+func (f *Fake) focusedSpace() *Space {
+	return f.space(f.visible[f.focusedDisplay])
+}
+
+func (f *Fake) spaceBySelector(sel string) *Space {
+	switch sel {
+	case "next", "prev":
+		current := f.focusedSpace()
+		if current == nil {
+			return nil
+		}
+		i := current.Index
+		if sel == "next" {
+			i++
+		} else {
+			i--
+		}
+		sel = strconv.Itoa(i)
+	}
+	for _, s := range f.spaces {
+		if s.Label == sel {
+			return s
+		}
+	}
+	index, err := strconv.Atoi(sel)
+	if err != nil || index < 1 || index > len(f.spaces) {
+		return nil
+	}
+	return f.spaces[index-1]
+}
+
+func (f *Fake) windowBySelector(from *Window, sel string) *Window {
+	if id, err := strconv.Atoi(sel); err == nil {
+		return f.window(id)
+	}
+	return f.neighbour(from, sel)
+}
+
+func (f *Fake) spaceWindows(spaceID int) []*Window {
+	windows := []*Window{}
+	for _, w := range f.windows {
+		if f.windowSpace[w.ID] == spaceID {
+			windows = append(windows, w)
+		}
+	}
+	return windows
+}
+
+func (f *Fake) firstWindow(spaceID int) int {
+	for _, w := range f.spaceWindows(spaceID) {
+		if !w.IsMinimized {
+			return w.ID
+		}
+	}
+	return 0
+}
+
+// neighbour finds the closest window on the same space in the direction
+// north, east, south or west of w.
+func (f *Fake) neighbour(w *Window, direction string) *Window {
+	if w == nil {
+		return nil
+	}
+	var closest *Window
+	var closestDistance float32
+	for _, o := range f.spaceWindows(f.windowSpace[w.ID]) {
+		if o == w {
+			continue
+		}
+		var distance float32
+		switch direction {
+		case "east":
+			distance = o.Frame.X - (w.Frame.X + w.Frame.Width)
+		case "west":
+			distance = w.Frame.X - (o.Frame.X + o.Frame.Width)
+		case "south":
+			distance = o.Frame.Y - (w.Frame.Y + w.Frame.Height)
+		case "north":
+			distance = w.Frame.Y - (o.Frame.Y + o.Frame.Height)
+		default:
+			return nil
+		}
+		if distance < 0 || !overlaps(w.Frame, o.Frame, direction) {
+			continue
+		}
+		if closest == nil || distance < closestDistance {
+			closest = o
+			closestDistance = distance
+		}
+	}
+	return closest
+}
+
+func overlaps(a, b *Frame, direction string) bool {
+	if direction == "east" || direction == "west" {
+		return a.Y < b.Y+b.Height && b.Y < a.Y+a.Height
+	}
+	return a.X < b.X+b.Width && b.X < a.X+a.Width
+}
+
+func (f *Fake) focusWindow(w *Window) {
+	spaceID := f.windowSpace[w.ID]
+	displayID := f.spaceDisplay[spaceID]
+	f.visible[displayID] = spaceID
+	f.focusedDisplay = displayID
+	f.focusedWindow = w.ID
+}
+
+func (f *Fake) focusSpace(spaceID int) {
+	displayID := f.spaceDisplay[spaceID]
+	f.visible[displayID] = spaceID
+	f.focusedDisplay = displayID
+	f.focusedWindow = f.firstWindow(spaceID)
+}
+
+func (f *Fake) swap(a, b *Window) {
+	ai := slices.Index(f.windows, a)
+	bi := slices.Index(f.windows, b)
+	f.windows[ai], f.windows[bi] = b, a
+	a.Frame, b.Frame = b.Frame, a.Frame
+	f.windowSpace[a.ID], f.windowSpace[b.ID] = f.windowSpace[b.ID], f.windowSpace[a.ID]
+}
+
+// move applies a yabai move of the form abs:x:y or rel:dx:dy to a floating
+// window.
+func (f *Fake) move(w *Window, value string) error {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 || (parts[0] != "abs" && parts[0] != "rel") {
+		return fmt.Errorf("value '%s' is not a valid option for MOVE_SEL", value)
+	}
+	dx, errX := strconv.ParseFloat(parts[1], 32)
+	dy, errY := strconv.ParseFloat(parts[2], 32)
+	if errX != nil || errY != nil {
+		return fmt.Errorf("value '%s' is not a valid option for MOVE_SEL", value)
+	}
+	if !w.IsFloating {
+		return errors.New("cannot move a managed window")
+	}
+	if parts[0] == "abs" {
+		w.Frame.X, w.Frame.Y = float32(dx), float32(dy)
+	} else {
+		w.Frame.X += float32(dx)
+		w.Frame.Y += float32(dy)
+	}
+	return nil
+}
+
+// resize applies a yabai resize of the form edge:dx:dy. Tiled windows can only
+// move an edge that is shared with another window, floating windows can move
+// any edge.
+func (f *Fake) resize(w *Window, value string) error {
+	parts := strings.Split(value, ":")
+	if len(parts) != 3 {
+		return fmt.Errorf("value '%s' is not a valid option for RESIZE_SEL", value)
+	}
+	dx, errX := strconv.ParseFloat(parts[1], 32)
+	dy, errY := strconv.ParseFloat(parts[2], 32)
+	if errX != nil || errY != nil {
+		return fmt.Errorf("value '%s' is not a valid option for RESIZE_SEL", value)
+	}
+	x, y := float32(dx), float32(dy)
+
+	if parts[0] == "abs" {
+		w.Frame.Width, w.Frame.Height = x, y
+		return nil
+	}
+
+	edges := map[string]string{
+		"top":    "north",
+		"bottom": "south",
+		"left":   "west",
+		"right":  "east",
+	}
+	direction, ok := edges[parts[0]]
+	if !ok {
+		return fmt.Errorf("value '%s' is not a valid option for RESIZE_SEL", value)
+	}
+
+	var n *Window
+	if !w.IsFloating {
+		n = f.neighbour(w, direction)
+		if n == nil {
+			return errors.New("cannot locate a bsp node fence")
+		}
+	}
+
+	switch direction {
+	case "east":
+		w.Frame.Width += x
+		if n != nil {
+			n.Frame.X += x
+			n.Frame.Width -= x
+		}
+	case "west":
+		w.Frame.X += x
+		w.Frame.Width -= x
+		if n != nil {
+			n.Frame.Width += x
+		}
+	case "south":
+		w.Frame.Height += y
+		if n != nil {
+			n.Frame.Y += y
+			n.Frame.Height -= y
+		}
+	case "north":
+		w.Frame.Y += y
+		w.Frame.Height -= y
+		if n != nil {
+			n.Frame.Height += y
+		}
+	}
+	return nil
+}
+
+// retile splits the display frame evenly between the tiled windows of a
+// space, left to right.
+func (f *Fake) retile(spaceID int) {
+	d := f.display(f.spaceDisplay[spaceID])
+	if d == nil {
+		return
+	}
+	tiled := []*Window{}
+	for _, w := range f.spaceWindows(spaceID) {
+		if !w.IsFloating && !w.IsMinimized {
+			tiled = append(tiled, w)
+		}
+	}
+	for i, w := range tiled {
+		width := d.Frame.Width / float32(len(tiled))
+		w.Frame = &Frame{
+			X:      d.Frame.X + width*float32(i),
+			Y:      d.Frame.Y,
+			Width:  width,
+			Height: d.Frame.Height,
+		}
+	}
+}
+
+// sync recalculates every derived index and flag after a mutation.
+func (f *Fake) sync() {
+	displayIndex := map[int]int{}
+	for i, d := range f.displays {
+		d.Index = i + 1
+		d.SpaceIndexes = []int{}
+		displayIndex[d.ID] = d.Index
+	}
+
+	slices.SortStableFunc(f.spaces, func(a, b *Space) int {
+		return displayIndex[f.spaceDisplay[a.ID]] - displayIndex[f.spaceDisplay[b.ID]]
+	})
+	spaceIndex := map[int]int{}
+	for i, s := range f.spaces {
+		displayID := f.spaceDisplay[s.ID]
+		s.Index = i + 1
+		s.DisplayIndex = displayIndex[displayID]
+		s.IsVisible = f.visible[displayID] == s.ID
+		s.HasFocus = s.IsVisible && displayID == f.focusedDisplay
+		s.WindowIDs = []int{}
+		for _, w := range f.spaceWindows(s.ID) {
+			s.WindowIDs = append(s.WindowIDs, w.ID)
+		}
+		s.FirstWindowID = 0
+		s.LastWindowID = 0
+		if len(s.WindowIDs) > 0 {
+			s.FirstWindowID = s.WindowIDs[0]
+			s.LastWindowID = s.WindowIDs[len(s.WindowIDs)-1]
+		}
+		spaceIndex[s.ID] = s.Index
+
+		d := f.display(displayID)
+		d.SpaceIndexes = append(d.SpaceIndexes, s.Index)
+	}
+
+	for _, w := range f.windows {
+		spaceID := f.windowSpace[w.ID]
+		w.Space = spaceIndex[spaceID]
+		w.Display = displayIndex[f.spaceDisplay[spaceID]]
+		w.HasFocus = w.ID == f.focusedWindow
+		w.IsVisible = f.visible[f.spaceDisplay[spaceID]] == spaceID
+	}
+}
+
+// clone deep copies v the same way a round trip through yabai's json output
+// would, so callers can't mutate the fake's state.
+func clone[T any](v T) (T, error) {
+	var result T
+	b, err := json.Marshal(v)
+	if err != nil {
+		return result, err
+	}
+	err = json.Unmarshal(b, &result)
+	return result, err
+}
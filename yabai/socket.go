@@ -0,0 +1,124 @@
+package yabai
+
+import (
+	"bytes"
+	"context"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"net"
+	"os"
+	"os/user"
+	"strings"
+	"time"
+
+	"github.com/abibby/salusa/di"
+)
+
+// failureMessage is the first byte of a response when yabai could not
+// process a message.
+const failureMessage = 0x07
+
+const DefaultTimeout = 2 * time.Second
+
+// SocketClient talks to the yabai daemon directly over its UNIX socket using
+// the same framing as `yabai -m`: a native endian int32 length followed by
+// each argument terminated by a null byte.
+//
+// yabai answers a single message per connection and closes it once the
+// response is written, so the client keeps one dialer and opens a new
+// connection for every message instead of forking a process.
+type SocketClient struct {
+	queries
+	path   string
+	dialer *net.Dialer
+
+	Timeout time.Duration
+}
+
+var _ Client = (*SocketClient)(nil)
+
+func NewSocketClient(path string) *SocketClient {
+	c := &SocketClient{
+		path:    path,
+		dialer:  &net.Dialer{},
+		Timeout: DefaultTimeout,
+	}
+	c.queries = queries{message: c.message}
+	return c
+}
+
+// SocketPath returns the path yabai listens on for the current user.
+func SocketPath() string {
+	name := os.Getenv("USER")
+	if name == "" {
+		if u, err := user.Current(); err == nil {
+			name = u.Username
+		}
+	}
+	return fmt.Sprintf("/tmp/yabai_%s.socket", name)
+}
+
+func (c *SocketClient) message(v any, args ...string) error {
+	ctx, cancel := context.WithTimeout(context.Background(), c.Timeout)
+	defer cancel()
+
+	conn, err := c.dialer.DialContext(ctx, "unix", c.path)
+	if err != nil {
+		return fmt.Errorf("yabai: connect: %w", err)
+	}
+	defer conn.Close()
+
+	deadline, _ := ctx.Deadline()
+	err = conn.SetDeadline(deadline)
+	if err != nil {
+		return fmt.Errorf("yabai: connect: %w", err)
+	}
+
+	_, err = conn.Write(encodeMessage(args))
+	if err != nil {
+		return fmt.Errorf("yabai: send: %w", err)
+	}
+	if uc, ok := conn.(*net.UnixConn); ok {
+		err = uc.CloseWrite()
+		if err != nil {
+			return fmt.Errorf("yabai: send: %w", err)
+		}
+	}
+
+	b, err := io.ReadAll(conn)
+	if err != nil {
+		return fmt.Errorf("yabai: receive: %w", err)
+	}
+
+	if len(b) > 0 && b[0] == failureMessage {
+		return &Error{
+			Args:    args,
+			Message: strings.TrimSpace(string(b[1:])),
+		}
+	}
+	if v == nil {
+		return nil
+	}
+	return json.Unmarshal(b, v)
+}
+
+// encodeMessage frames args the way the yabai daemon expects to read them.
+func encodeMessage(args []string) []byte {
+	body := &bytes.Buffer{}
+	for _, arg := range args {
+		body.WriteString(arg)
+		body.WriteByte(0)
+	}
+	body.WriteByte(0)
+
+	b := binary.NativeEndian.AppendUint32(nil, uint32(body.Len()))
+	return append(b, body.Bytes()...)
+}
+
+func RegisterSocket(ctx context.Context) {
+	di.RegisterSingleton(ctx, func() Client {
+		return NewSocketClient(SocketPath())
+	})
+}
@@ -0,0 +1,120 @@
+package yabai
+
+import (
+	"bytes"
+	"encoding/binary"
+	"io"
+	"net"
+	"path"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// decodeMessage is the inverse of encodeMessage.
+func decodeMessage(r io.Reader) ([]string, error) {
+	var length uint32
+	err := binary.Read(r, binary.NativeEndian, &length)
+	if err != nil {
+		return nil, err
+	}
+	body := make([]byte, length)
+	_, err = io.ReadFull(r, body)
+	if err != nil {
+		return nil, err
+	}
+	body = bytes.TrimSuffix(body, []byte{0})
+	if len(body) == 0 {
+		return []string{}, nil
+	}
+	body = bytes.TrimSuffix(body, []byte{0})
+	return strings.Split(string(body), "\x00"), nil
+}
+
+// fakeSocket serves yabai's socket protocol, answering every message with
+// handler and recording the received args.
+func fakeSocket(t *testing.T, handler func(args []string) []byte) (string, chan []string) {
+	socket := path.Join(t.TempDir(), "yabai.socket")
+	l, err := net.Listen("unix", socket)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { l.Close() })
+
+	messages := make(chan []string, 16)
+	go func() {
+		for {
+			c, err := l.Accept()
+			if err != nil {
+				return
+			}
+			args, err := decodeMessage(c)
+			if err != nil {
+				c.Close()
+				continue
+			}
+			messages <- args
+			_, _ = c.Write(handler(args))
+			c.Close()
+		}
+	}()
+	return socket, messages
+}
+
+func TestEncodeMessage(t *testing.T) {
+	testCases := [][]string{
+		{},
+		{"query", "--spaces"},
+		{"space", "1", "--label", "1: web"},
+	}
+	for _, args := range testCases {
+		t.Run(strings.Join(args, " "), func(t *testing.T) {
+			result, err := decodeMessage(bytes.NewReader(encodeMessage(args)))
+			assert.NoError(t, err)
+			assert.Equal(t, args, result)
+		})
+	}
+}
+
+func TestSocketClient_query(t *testing.T) {
+	socket, messages := fakeSocket(t, func(args []string) []byte {
+		return []byte(`[{"id":3,"index":1,"label":"web","has-focus":true}]`)
+	})
+
+	spaces, err := NewSocketClient(socket).QuerySpaces()
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"query", "--spaces"}, <-messages)
+	assert.Equal(t, []*Space{{ID: 3, Index: 1, Label: "web", HasFocus: true}}, spaces)
+}
+
+func TestSocketClient_failure(t *testing.T) {
+	socket, _ := fakeSocket(t, func(args []string) []byte {
+		return []byte("\x07could not locate a northward managed window.\n")
+	})
+
+	err := NewSocketClient(socket).Yabai("window", "--focus", "north")
+	yabaiErr := &Error{}
+	if assert.ErrorAs(t, err, &yabaiErr) {
+		assert.Equal(t, []string{"window", "--focus", "north"}, yabaiErr.Args)
+		assert.Equal(t, "could not locate a northward managed window.", yabaiErr.Message)
+	}
+}
+
+func TestSocketClient_timeout(t *testing.T) {
+	socket, _ := fakeSocket(t, func(args []string) []byte {
+		time.Sleep(200 * time.Millisecond)
+		return nil
+	})
+
+	c := NewSocketClient(socket)
+	c.Timeout = 20 * time.Millisecond
+	err := c.Yabai("space", "--focus", "1")
+	assert.Error(t, err)
+}
+
+func TestSocketClient_noDaemon(t *testing.T) {
+	err := NewSocketClient(path.Join(t.TempDir(), "missing.socket")).Yabai("query", "--spaces")
+	assert.Error(t, err)
+}
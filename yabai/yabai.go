@@ -1,10 +1,7 @@
 package yabai
 
 import (
-	"encoding/json"
-	"errors"
 	"fmt"
-	"os/exec"
 	"strings"
 )
 
@@ -70,63 +67,75 @@ type Display struct {
 	SpaceIndexes []int  `json:"spaces"`
 }
 
-func Yabai(args ...string) error {
-	return yabaiReturn(nil, args...)
+type Client interface {
+	Yabai(args ...string) error
+	QuerySpaces() ([]*Space, error)
+	QueryActiveSpace() (*Space, error)
+	QueryWindows() ([]*Window, error)
+	QueryActiveWindow() (*Window, error)
+	QueryDisplays() ([]*Display, error)
 }
 
-func yabaiReturn(v any, args ...string) error {
-	fmt.Printf("yabai -m %s\n", strings.Join(args, " "))
-	b, err := exec.Command("yabai", append([]string{"-m"}, args...)...).CombinedOutput()
-	if err != nil {
-		return errors.New(strings.TrimSpace(string(b)))
-	}
-	if v == nil {
-		return nil
-	}
+// queries implements Client on top of a single message function that sends
+// args to yabai and decodes the json response into v.
+type queries struct {
+	message func(v any, args ...string) error
+}
 
-	return json.Unmarshal(b, v)
+func (q queries) Yabai(args ...string) error {
+	return q.message(nil, args...)
 }
 
-func QuerySpaces() ([]*Space, error) {
+func (q queries) QuerySpaces() ([]*Space, error) {
 	s := []*Space{}
-	err := yabaiReturn(&s, "query", "--spaces")
+	err := q.message(&s, "query", "--spaces")
 	if err != nil {
 		return nil, err
 	}
 	return s, nil
 }
-func QueryActiveSpace() (*Space, error) {
+func (q queries) QueryActiveSpace() (*Space, error) {
 	s := &Space{}
-	err := yabaiReturn(s, "query", "--spaces", "--space")
+	err := q.message(s, "query", "--spaces", "--space")
 	if err != nil {
 		return nil, err
 	}
 	return s, nil
 }
 
-func QueryWindows() ([]*Window, error) {
+func (q queries) QueryWindows() ([]*Window, error) {
 	w := []*Window{}
-	err := yabaiReturn(&w, "query", "--windows")
+	err := q.message(&w, "query", "--windows")
 	if err != nil {
 		return nil, err
 	}
 	return w, nil
 }
 
-func QueryActiveWindow() (*Window, error) {
+func (q queries) QueryActiveWindow() (*Window, error) {
 	w := &Window{}
-	err := yabaiReturn(&w, "query", "--windows", "--window")
+	err := q.message(w, "query", "--windows", "--window")
 	if err != nil {
 		return nil, err
 	}
 	return w, nil
 }
 
-func QueryDisplays() ([]*Display, error) {
+func (q queries) QueryDisplays() ([]*Display, error) {
 	d := []*Display{}
-	err := yabaiReturn(&d, "query", "--displays")
+	err := q.message(&d, "query", "--displays")
 	if err != nil {
 		return nil, err
 	}
 	return d, nil
 }
+
+// Error is returned when yabai rejects a message.
+type Error struct {
+	Args    []string
+	Message string
+}
+
+func (e *Error) Error() string {
+	return fmt.Sprintf("yabai -m %s: %s", strings.Join(e.Args, " "), e.Message)
+}
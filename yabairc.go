@@ -1,41 +1,25 @@
 package main
 
 import (
+	"context"
 	"log"
 
-	"github.com/abibby/yabai3/badparser"
-	"github.com/abibby/yabai3/run"
+	"github.com/abibby/salusa/di"
 	"github.com/abibby/yabai3/yabai"
 )
 
-func Yabairc() {
-	modeAST, err := readConfig()
+func Yabairc(ctx context.Context) {
+	y, err := di.Resolve[yabai.Client](ctx)
 	if err != nil {
-		log.Fatalf("failed to load config: %v", err)
-	}
-
-	var defaultMode *badparser.Mode
-	for _, mode := range modeAST {
-		if mode.Name == "default" {
-			defaultMode = mode
-		}
-	}
-	if defaultMode == nil {
-		log.Fatal("no default mode")
+		log.Fatal(err)
 	}
-	err = yabai.Yabai("config", "layout", "bsp")
+	cfg, err := readConfig()
 	if err != nil {
-		log.Print(err)
-	}
-	spaceCache := map[int]struct{}{}
-	for _, w := range defaultMode.Workspaces {
-		err := run.LabelSpace(spaceCache, w.DisplayIndexes, w.WorkspaceName)
-		if err != nil {
-			log.Print(err)
-		}
+		log.Fatalf("failed to load config: %v", err)
 	}
-	err = run.SetGaps(defaultMode.Borders.Inner, defaultMode.Borders.Outer)
+	err = y.Yabai("config", "layout", "bsp")
 	if err != nil {
 		log.Print(err)
 	}
+	applyConfig(y, cfg)
 }